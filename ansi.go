@@ -31,6 +31,39 @@ const (
 	Strikethrough TextStyle = 1 << 7
 	// Bright Style
 	Bright TextStyle = 1 << 8
+	// RapidBlinking Style
+	RapidBlinking TextStyle = 1 << 9
+	// Fraktur Style
+	Fraktur TextStyle = 1 << 10
+	// Framed Style
+	Framed TextStyle = 1 << 11
+	// Encircled Style
+	Encircled TextStyle = 1 << 12
+	// Overlined Style
+	Overlined TextStyle = 1 << 13
+	// Superscript Style
+	Superscript TextStyle = 1 << 14
+	// Subscript Style
+	Subscript TextStyle = 1 << 15
+)
+
+// UnderlineStyle is a type representing the
+// ansi underline styles
+type UnderlineStyle int
+
+const (
+	// UnderlineNone is not underlined
+	UnderlineNone UnderlineStyle = 0
+	// UnderlineSingle is a single underline
+	UnderlineSingle UnderlineStyle = 1
+	// UnderlineDouble is a double underline
+	UnderlineDouble UnderlineStyle = 2
+	// UnderlineCurly is a curly underline
+	UnderlineCurly UnderlineStyle = 3
+	// UnderlineDotted is a dotted underline
+	UnderlineDotted UnderlineStyle = 4
+	// UnderlineDashed is a dashed underline
+	UnderlineDashed UnderlineStyle = 5
 )
 
 type ColourMode int
@@ -41,25 +74,54 @@ const (
 	TrueColour ColourMode = 2
 )
 
-var invalid = fmt.Errorf("invalid ansi string")
-var missingTerminator = fmt.Errorf("missing escape terminator 'm'")
-var invalidTrueColorSequence = fmt.Errorf("invalid TrueColor sequence")
-var invalid256ColSequence = fmt.Errorf("invalid 256 colour sequence")
+// ErrInvalid is the kind of error returned for malformed escape
+// sequences and unexpected SGR parameters
+var ErrInvalid = fmt.Errorf("invalid ansi string")
 
-const (
-	// Default colors uses foreground color codes [30-37].
-	// See ColourMap and case for background colors.
-	defaultForegroundColor = "37"
-	defaultBackgroundColor = "30"
-)
+// ErrMissingTerminator is the kind of error returned when the input
+// ends part way through an escape sequence
+var ErrMissingTerminator = fmt.Errorf("missing escape terminator")
+
+// ErrInvalidTrueColorSequence is the kind of error returned for
+// malformed true colour parameters
+var ErrInvalidTrueColorSequence = fmt.Errorf("invalid TrueColor sequence")
+
+// ErrInvalid256ColSequence is the kind of error returned for
+// malformed 256 colour parameters
+var ErrInvalid256ColSequence = fmt.Errorf("invalid 256 colour sequence")
 
 // StyledText represents a single formatted string
 type StyledText struct {
-	Label      string
-	FgCol      *Col
-	BgCol      *Col
-	Style      TextStyle
+	Label string
+	// FgCol is the foreground colour. A nil FgCol is the
+	// terminal's default foreground colour.
+	FgCol *Col
+	// BgCol is the background colour. A nil BgCol is the
+	// terminal's default background colour.
+	BgCol *Col
+	Style TextStyle
+	// FgMode is the colour mode of the foreground colour
+	FgMode ColourMode
+	// BgMode is the colour mode of the background colour
+	BgMode ColourMode
+	// ColourMode is the colour mode used for both colours when
+	// building a string, if FgMode and BgMode are both Default.
+	// Parse sets it to the mode of both colours when they have the
+	// same mode, or to the mode of the only 256 or true colour.
+	//
+	// Deprecated: Use FgMode and BgMode instead.
 	ColourMode ColourMode
+	// Underline is the style of underline. The Underlined style is
+	// set for every style other than UnderlineNone.
+	Underline UnderlineStyle
+	// Font is the index of the alternative font, 1 to 9,
+	// or 0 for the primary font
+	Font int
+	// UlCol is the underline colour. A nil UlCol is the
+	// colour of the text.
+	UlCol *Col
+	// Hyperlink is the OSC 8 hyperlink the text belongs to, if any
+	Hyperlink *Hyperlink
 	// Offset is the offset into the input string where the StyledText begins
 	Offset int
 	// Len is the length in bytes of the substring of the input text that
@@ -67,84 +129,82 @@ type StyledText struct {
 	Len int
 }
 
-func (s *StyledText) styleToParams() []string {
-	var params []string
-	if s.Bold() {
-		params = append(params, "1")
-	}
-	if s.Faint() {
-		params = append(params, "2")
-	}
-	if s.Italic() {
-		params = append(params, "3")
-	}
-	if s.Underlined() {
-		params = append(params, "4")
-	}
-	if s.Blinking() {
-		params = append(params, "5")
-	}
-	if s.Inversed() {
-		params = append(params, "7")
-	}
-	if s.Invisible() {
-		params = append(params, "8")
+// Hyperlink represents an OSC 8 hyperlink
+type Hyperlink struct {
+	URL string
+	// ID is the optional id parameter, used by terminals to
+	// group text belonging to the same link
+	ID string
+}
+
+// fgMode returns the colour mode of the foreground colour,
+// falling back to the deprecated ColourMode
+func (s *StyledText) fgMode() ColourMode {
+	if s.FgMode == Default && s.BgMode == Default {
+		return s.ColourMode
 	}
-	if s.Strikethrough() {
-		params = append(params, "9")
+	return s.FgMode
+}
+
+// bgMode returns the colour mode of the background colour,
+// falling back to the deprecated ColourMode
+func (s *StyledText) bgMode() ColourMode {
+	if s.FgMode == Default && s.BgMode == Default {
+		return s.ColourMode
 	}
-	if s.FgCol != nil {
-		// Do we have an ID?
-		switch s.ColourMode {
-		case Default:
-			offset := 30
-			id := s.FgCol.Id
-			// Adjust when bold has been applied to the id
-			if (s.Bold() || s.Bright()) && id > 7 && id < 16 {
-				id -= 8
-			}
-			if s.Bright() {
-				offset = 90
-			}
-			params = append(params, fmt.Sprintf("%d", id+offset))
-		case TwoFiveSix:
-			params = append(params, []string{"38", "5", fmt.Sprintf("%d", s.FgCol.Id)}...)
-		case TrueColour:
-			r := fmt.Sprintf("%d", s.FgCol.Rgb.R)
-			g := fmt.Sprintf("%d", s.FgCol.Rgb.G)
-			b := fmt.Sprintf("%d", s.FgCol.Rgb.B)
-			params = append(params, []string{"38", "2", r, g, b}...)
+	return s.BgMode
+}
+
+// extendedColourParams returns the parameters for a 256 or true colour,
+// introduced by the given code (38, 48 or 58)
+func extendedColourParams(code string, col *Col, mode ColourMode, colon bool) []string {
+	var params []string
+	if mode == TwoFiveSix {
+		params = []string{code, "5", strconv.Itoa(col.Id)}
+	} else {
+		r := strconv.Itoa(int(col.Rgb.R))
+		g := strconv.Itoa(int(col.Rgb.G))
+		b := strconv.Itoa(int(col.Rgb.B))
+		params = []string{code, "2", r, g, b}
+		if colon {
+			// Include an empty colour space ID
+			params = []string{code, "2", "", r, g, b}
 		}
 	}
-	if s.BgCol != nil {
-		// Do we have an ID?
-		switch s.ColourMode {
-		case Default:
-			id := s.BgCol.Id
-			offset := 40
-			if s.Bright() {
-				offset = 100
-			}
-			// Adjust when bold has been applied to the id
-			if (s.Bold() || s.Bright()) && id > 7 && id < 16 {
-				id -= 8
-			}
-			params = append(params, fmt.Sprintf("%d", id+offset))
-		case TwoFiveSix:
-			params = append(params, []string{"48", "5", fmt.Sprintf("%d", s.BgCol.Id)}...)
-		case TrueColour:
-			r := fmt.Sprintf("%d", s.BgCol.Rgb.R)
-			g := fmt.Sprintf("%d", s.BgCol.Rgb.G)
-			b := fmt.Sprintf("%d", s.BgCol.Rgb.B)
-			params = append(params, []string{"48", "2", r, g, b}...)
-		}
+	if colon {
+		return []string{strings.Join(params, ":")}
 	}
 	return params
 }
 
 func (s *StyledText) String() string {
-	params := strings.Join(s.styleToParams(), ";")
-	return "\033[0;" + params + "m" + s.Label + "\033[0m"
+	return s.string(false)
+}
+
+// string builds the ANSI string for s
+func (s *StyledText) string(colon bool) string {
+	params := strings.Join(s.styleToParams(colon), ";")
+	return s.linkStart() + "\033[0;" + params + "m" + s.Label + "\033[0m" + s.linkEnd()
+}
+
+// linkStart returns the OSC 8 sequence that opens the hyperlink
+func (s *StyledText) linkStart() string {
+	if s.Hyperlink == nil {
+		return ""
+	}
+	var params string
+	if s.Hyperlink.ID != "" {
+		params = "id=" + s.Hyperlink.ID
+	}
+	return "\033]8;" + params + ";" + s.Hyperlink.URL + "\033\\"
+}
+
+// linkEnd returns the OSC 8 sequence that closes the hyperlink
+func (s *StyledText) linkEnd() string {
+	if s.Hyperlink == nil {
+		return ""
+	}
+	return "\033]8;;\033\\"
 }
 
 // Bold will return true if the text has a Bold style
@@ -192,6 +252,41 @@ func (s *StyledText) Bright() bool {
 	return s.Style&Bright == Bright
 }
 
+// RapidBlinking will return true if the text has a RapidBlinking style
+func (s *StyledText) RapidBlinking() bool {
+	return s.Style&RapidBlinking == RapidBlinking
+}
+
+// Fraktur will return true if the text has a Fraktur style
+func (s *StyledText) Fraktur() bool {
+	return s.Style&Fraktur == Fraktur
+}
+
+// Framed will return true if the text has a Framed style
+func (s *StyledText) Framed() bool {
+	return s.Style&Framed == Framed
+}
+
+// Encircled will return true if the text has an Encircled style
+func (s *StyledText) Encircled() bool {
+	return s.Style&Encircled == Encircled
+}
+
+// Overlined will return true if the text has an Overlined style
+func (s *StyledText) Overlined() bool {
+	return s.Style&Overlined == Overlined
+}
+
+// Superscript will return true if the text has a Superscript style
+func (s *StyledText) Superscript() bool {
+	return s.Style&Superscript == Superscript
+}
+
+// Subscript will return true if the text has a Subscript style
+func (s *StyledText) Subscript() bool {
+	return s.Style&Subscript == Subscript
+}
+
 // ColourMap maps ansi identifiers to a colour
 var ColourMap = map[string]map[string]*Col{
 	"Regular": {
@@ -247,224 +342,495 @@ var ColourMap = map[string]map[string]*Col{
 		"107": Cols[15],
 	},
 	"Faint": {
-		"30": Cols[0],
-		"31": Cols[1],
-		"32": Cols[2],
-		"33": Cols[3],
-		"34": Cols[4],
-		"35": Cols[5],
-		"36": Cols[6],
-		"37": Cols[7],
+		"30":  Cols[0],
+		"31":  Cols[1],
+		"32":  Cols[2],
+		"33":  Cols[3],
+		"34":  Cols[4],
+		"35":  Cols[5],
+		"36":  Cols[6],
+		"37":  Cols[7],
+		"90":  Cols[8],
+		"91":  Cols[9],
+		"92":  Cols[10],
+		"93":  Cols[11],
+		"94":  Cols[12],
+		"95":  Cols[13],
+		"96":  Cols[14],
+		"97":  Cols[15],
+		"100": Cols[8],
+		"101": Cols[9],
+		"102": Cols[10],
+		"103": Cols[11],
+		"104": Cols[12],
+		"105": Cols[13],
+		"106": Cols[14],
+		"107": Cols[15],
 	},
 }
 
 // Parse will convert an ansi encoded string and return
 // a slice of StyledText structs that represent the text.
-// If parsing is unsuccessful, an error is returned.
+// If parsing is unsuccessful, a *ParseError is returned.
 func Parse(input string, options ...ParseOption) ([]*StyledText, error) {
-	var result []*StyledText
-	index := 0
-	offset := 0
-	escapeCodeLen := 0
-	var currentStyledText = &StyledText{}
+	var state StyledText
+	config := newParseConfig(options)
+	return parse(input, &state, &config)
+}
 
+// parse parses input starting with the style in state. If parsing is
+// successful, state is updated to the style at the end of the input.
+func parse(input string, state *StyledText, config *parseConfig) ([]*StyledText, error) {
 	if len(input) == 0 {
-		return []*StyledText{currentStyledText}, nil
+		result := *state
+		result.Label = ""
+		result.Offset = 0
+		result.Len = 0
+		return []*StyledText{&result}, nil
+	}
+	var result []*StyledText
+	err := each(input, state, config, func(text StyledText) bool {
+		result = append(result, &text)
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	for {
-		// Read all chars to next escape code
-		esc := strings.Index(input, "\033[")
-
-		// If no more esc chars, save what's left and return
-		if esc == -1 {
-			text := input[index:]
-			if len(text) > 0 {
-				currentStyledText.Label = text
-				currentStyledText.Offset = offset
-				currentStyledText.Len = len(text) + escapeCodeLen
-				result = append(result, currentStyledText)
-			}
-			return result, nil
+// applySequence applies the control sequence or operating system
+// command at the start of input to s. It returns the length of the
+// sequence in bytes. On error, the length covers the bytes of the
+// malformed sequence, and s may have been partly changed.
+func (s *StyledText) applySequence(input string, config *parseConfig) (int, error) {
+	if input[1] == ']' {
+		osc, length, err := scanOSC(input)
+		if err != nil {
+			return length, sequenceError(err, input[:length])
 		}
-		label := input[:esc]
-		if len(label) > 0 {
-			currentStyledText.Label = label
-			currentStyledText.Offset = offset
-			currentStyledText.Len = len(label) + escapeCodeLen
-			offset += currentStyledText.Len
-			result = append(result, currentStyledText)
-			currentStyledText = &StyledText{
-				Label: "",
-				FgCol: currentStyledText.FgCol,
-				BgCol: currentStyledText.BgCol,
-				Style: currentStyledText.Style,
-			}
-			escapeCodeLen = 0
+		// Hyperlinks are the only supported command
+		if osc.command == "8" {
+			s.Hyperlink = osc.hyperlink()
+		}
+		return length, nil
+	}
+
+	csi, length, err := scanCSI(input)
+	if err != nil {
+		return length, sequenceError(err, input[:length])
+	}
+	// Only SGR sequences affect the style. Other control sequences,
+	// such as cursor movement, are skipped.
+	if !csi.isSGR() {
+		return length, nil
+	}
+	if err := s.applySGR(csi.params, config); err != nil {
+		return length, sequenceError(err, input[:length])
+	}
+	return length, nil
+}
+
+// applySGR applies the given SGR parameter text to the style of s
+func (s *StyledText) applySGR(paramText string, config *parseConfig) error {
+	var buf [maxParams]string
+	params := splitParams(paramText, ';', buf[:0])
+	skip := 0
+	for index, param := range params {
+		if skip > 0 {
+			skip--
+			continue
 		}
-		input = input[esc:]
-		// skip
-		input = input[2:]
-
-		// Read in params
-		endesc := strings.Index(input, "m")
-		if endesc == -1 {
-			return nil, missingTerminator
+		if strings.IndexByte(param, ':') != -1 {
+			var subBuf [maxParams]string
+			if err := s.applySubParams(splitParams(param, ':', subBuf[:0]), config); err != nil {
+				return paramError(err, param)
+			}
+			continue
 		}
-		paramText := input[:endesc]
-		input = input[endesc+1:]
-		escapeCodeLen += 2 + endesc + 1
-		params := strings.Split(paramText, ";")
-		colourMap := ColourMap["Regular"]
-		skip := 0
-		for index, param := range params {
-			if skip > 0 {
-				skip--
+		param = stripLeadingZeros(param)
+		switch param {
+		case "0", "":
+			s.Style = 0
+			s.Underline = UnderlineNone
+			s.Font = 0
+			s.FgCol = nil
+			s.BgCol = nil
+			s.FgMode = Default
+			s.BgMode = Default
+			s.UlCol = nil
+		case "1":
+			// Bold
+			s.Style |= Bold
+		case "2":
+			// Dim/Feint
+			s.Style |= Faint
+		case "3":
+			// Italic
+			s.Style |= Italic
+		case "4":
+			// Underlined
+			s.setUnderline(UnderlineSingle)
+		case "5":
+			// Blinking
+			s.Style |= Blinking
+		case "6":
+			// Rapid blinking
+			s.Style |= RapidBlinking
+		case "7":
+			// Inversed
+			s.Style |= Inversed
+		case "8":
+			// Invisible
+			s.Style |= Invisible
+		case "9":
+			// Strikethrough
+			s.Style |= Strikethrough
+		case "10":
+			// Primary font
+			s.Font = 0
+		case "11", "12", "13", "14", "15", "16", "17", "18", "19":
+			// Alternative font
+			s.Font = int(param[1] - '0')
+		case "20":
+			// Fraktur
+			s.Style |= Fraktur
+		case "21":
+			// Doubly underlined
+			s.setUnderline(UnderlineDouble)
+		case "22":
+			// Normal intensity
+			s.normalIntensity(config)
+		case "23":
+			// Not italic or fraktur
+			s.Style &^= Italic | Fraktur
+		case "24":
+			// Not underlined
+			s.setUnderline(UnderlineNone)
+		case "25":
+			// Not blinking
+			s.Style &^= Blinking | RapidBlinking
+		case "27":
+			// Not inversed
+			s.Style &^= Inversed
+		case "28":
+			// Not invisible
+			s.Style &^= Invisible
+		case "29":
+			// Not strikethrough
+			s.Style &^= Strikethrough
+		case "30", "31", "32", "33", "34", "35", "36", "37":
+			s.FgCol = config.colourMap(s.Style)[param]
+			s.FgMode = Default
+		case "90", "91", "92", "93", "94", "95", "96", "97":
+			s.FgCol = config.regular[param]
+			s.FgMode = Default
+			s.Style |= Bright
+		case "100", "101", "102", "103", "104", "105", "106", "107":
+			s.BgCol = config.regular[param]
+			s.BgMode = Default
+			s.Style |= Bright
+		case "40", "41", "42", "43", "44", "45", "46", "47":
+			bgcol := "3" + param[1:] // Equivalent of -10
+			s.BgCol = config.colourMap(s.Style)[bgcol]
+			s.BgMode = Default
+		case "38", "48":
+			col, mode, consumed, err := parseExtendedColour(params[index+1:], config.colours.cols)
+			if err != nil {
+				return paramError(err, extendedParam(params[index:]))
+			}
+			skip = consumed
+			if param == "38" {
+				s.FgCol = col
+				s.FgMode = mode
 				continue
 			}
-			param = stripLeadingZeros(param)
-			switch param {
-			case "0", "":
-				colourMap = ColourMap["Regular"]
-				currentStyledText.Style = 0
-				currentStyledText.FgCol = nil
-				currentStyledText.BgCol = nil
-			case "1":
-				// Bold
-				colourMap = ColourMap["Bold"]
-				currentStyledText.Style |= Bold
-			case "2":
-				// Dim/Feint
-				colourMap = ColourMap["Faint"]
-				currentStyledText.Style |= Faint
-			case "3":
-				// Italic
-				currentStyledText.Style |= Italic
-			case "4":
-				// Underlined
-				currentStyledText.Style |= Underlined
-			case "5":
-				// Blinking
-				currentStyledText.Style |= Blinking
-			case "7":
-				// Inversed
-				currentStyledText.Style |= Inversed
-			case "8":
-				// Invisible
-				currentStyledText.Style |= Invisible
-			case "9":
-				// Strikethrough
-				currentStyledText.Style |= Strikethrough
-			case "30", "31", "32", "33", "34", "35", "36", "37":
-				currentStyledText.FgCol = colourMap[param]
-			case "90", "91", "92", "93", "94", "95", "96", "97":
-				currentStyledText.FgCol = colourMap[param]
-				currentStyledText.Style |= Bright
-			case "100", "101", "102", "103", "104", "105", "106", "107":
-				currentStyledText.BgCol = colourMap[param]
-				currentStyledText.Style |= Bright
-			case "40", "41", "42", "43", "44", "45", "46", "47":
-				bgcol := "3" + param[1:] // Equivalent of -10
-				currentStyledText.BgCol = colourMap[bgcol]
-			case "38", "48":
-				if len(params)-index < 3 {
-					return nil, invalid
-				}
-				// 256 colours
-				param1 := stripLeadingZeros(params[index+1])
-				if param1 == "5" {
-					skip = 2
-					colIndexText := stripLeadingZeros(params[index+2])
-					colIndex, err := strconv.Atoi(colIndexText)
-					if err != nil {
-						return nil, invalid256ColSequence
-					}
-					if colIndex < 0 || colIndex > 255 {
-						return nil, invalid256ColSequence
-					}
-					currentStyledText.ColourMode = TwoFiveSix
-					if param == "38" {
-						currentStyledText.FgCol = Cols[colIndex]
-						continue
-					}
-					currentStyledText.BgCol = Cols[colIndex]
-					continue
-				}
-				// we must have 4 params left
-				if len(params)-index < 5 {
-					return nil, invalidTrueColorSequence
-				}
-				if param1 != "2" {
-					return nil, invalidTrueColorSequence
-				}
-				var r, g, b uint8
-				ri, err := strconv.Atoi(params[index+2])
-				if err != nil {
-					return nil, invalidTrueColorSequence
-				}
-				gi, err := strconv.Atoi(params[index+3])
-				if err != nil {
-					return nil, invalidTrueColorSequence
-				}
-				bi, err := strconv.Atoi(params[index+4])
-				if err != nil {
-					return nil, invalidTrueColorSequence
-				}
-				if bi > 255 || gi > 255 || ri > 255 {
-					return nil, invalidTrueColorSequence
-				}
-				if bi < 0 || gi < 0 || ri < 0 {
-					return nil, invalidTrueColorSequence
-				}
-				r = uint8(ri)
-				g = uint8(gi)
-				b = uint8(bi)
-				skip = 4
-				colvalue := fmt.Sprintf("#%02x%02x%02x", r, g, b)
-				currentStyledText.ColourMode = TrueColour
-				if param == "38" {
-					currentStyledText.FgCol = &Col{Id: 256, Hex: colvalue, Rgb: Rgb{r, g, b}}
-					continue
-				}
-				currentStyledText.BgCol = &Col{Id: 256, Hex: colvalue, Rgb: Rgb{r, g, b}}
-			case "39":
-				// Lookup for default foreground color.
-				foregroundColor := colourMap[defaultForegroundColor]
-				for _, option := range options {
-					if option.ansiForegroundColor != "" {
-						foregroundColor = colourMap[option.ansiForegroundColor]
-						break
-					}
-				}
+			s.BgCol = col
+			s.BgMode = mode
+		case "51":
+			// Framed
+			s.Style |= Framed
+		case "52":
+			// Encircled
+			s.Style |= Encircled
+		case "53":
+			// Overlined
+			s.Style |= Overlined
+		case "54":
+			// Not framed or encircled
+			s.Style &^= Framed | Encircled
+		case "55":
+			// Not overlined
+			s.Style &^= Overlined
+		case "73":
+			// Superscript
+			s.Style &^= Subscript
+			s.Style |= Superscript
+		case "74":
+			// Subscript
+			s.Style &^= Superscript
+			s.Style |= Subscript
+		case "75":
+			// Not superscript or subscript
+			s.Style &^= Superscript | Subscript
+		case "58":
+			col, _, consumed, err := parseExtendedColour(params[index+1:], config.colours.cols)
+			if err != nil {
+				return paramError(err, extendedParam(params[index:]))
+			}
+			skip = consumed
+			s.UlCol = col
+		case "59":
+			// Default underline colour
+			s.UlCol = nil
+		case "39":
+			// Default foreground colour, unless one has been specified.
+			s.FgCol = nil
+			s.FgMode = Default
+			if config.foregroundColour != "" {
+				s.FgCol = config.colourMap(s.Style)[config.foregroundColour]
+			}
+		case "49":
+			// Default background colour, unless one has been specified.
+			s.BgCol = nil
+			s.BgMode = Default
+			if config.backgroundColour != "" {
+				s.BgCol = config.colourMap(s.Style)[config.backgroundColour]
+			}
+		default:
+			// Unexpected codes may be ignored.
+			if !config.ignoreUnexpectedCodes {
+				return paramError(ErrInvalid, param)
+			}
+		}
+	}
+	s.ColourMode = s.sharedColourMode()
+	return nil
+}
 
-				// Set selected foreground color.
-				currentStyledText.FgCol = foregroundColor
-			case "49":
-				// Lookup for default background color.
-				backgroundColor := colourMap[defaultBackgroundColor]
-				for _, option := range options {
-					if option.ansiBackgroundColor != "" {
-						backgroundColor = colourMap[option.ansiBackgroundColor]
-						break
-					}
-				}
+// normalIntensity turns off bold and faint. A 16 colour foreground or
+// background that was selected with the Bold or Faint colour map goes
+// back to the colour from the Regular map, eg. the bright red of bold
+// text in red becomes red. Colours are left as they are when s has the
+// Bright style, as the bright codes select the same colours.
+func (s *StyledText) normalIntensity(config *parseConfig) {
+	previous := config.colourMap(s.Style)
+	s.Style &^= Bold | Faint
+	if s.Bright() {
+		return
+	}
+	s.FgCol = regularColour(s.FgCol, s.FgMode, previous, config.regular)
+	s.BgCol = regularColour(s.BgCol, s.BgMode, previous, config.regular)
+}
 
-				// Set selected background color.
-				currentStyledText.BgCol = backgroundColor
-			default:
-				// Unexpected codes may be ignored.
-				unexpectedCodeIgnored := false
-				for _, option := range options {
-					if option.ignoreUnexpectedCode {
-						unexpectedCodeIgnored = true
-						break
-					}
-				}
-				if !unexpectedCodeIgnored {
-					return nil, invalid
-				}
-			}
+// regularColour returns the colour of the standard colour code that
+// selects col from previous, taken from regular. Other colours are
+// returned as they are.
+func regularColour(col *Col, mode ColourMode, previous, regular map[string]*Col) *Col {
+	if col == nil || mode != Default {
+		return col
+	}
+	for code := '0'; code < '8'; code++ {
+		param := "3" + string(code)
+		if previous[param] == col {
+			return regular[param]
+		}
+	}
+	return col
+}
+
+// sharedColourMode returns the colour mode of the colours of s, for
+// the deprecated ColourMode field. This is the mode of both colours
+// when they have the same mode, or the mode of the only colour that
+// is a 256 or true colour. Otherwise it is Default.
+func (s *StyledText) sharedColourMode() ColourMode {
+	switch {
+	case s.FgMode == s.BgMode, s.BgMode == Default:
+		return s.FgMode
+	case s.FgMode == Default:
+		return s.BgMode
+	}
+	return Default
+}
+
+// extendedParam returns the parameters making up the extended
+// colour that starts with params[0], eg. 38;5;208
+func extendedParam(params []string) string {
+	end := 2
+	if len(params) > 1 {
+		switch stripLeadingZeros(params[1]) {
+		case "5":
+			end = 3
+		case "2":
+			end = 5
+		}
+	}
+	if end > len(params) {
+		end = len(params)
+	}
+	return strings.Join(params[:end], ";")
+}
+
+// applySubParams applies an SGR parameter made up of colon separated
+// sub-parameters, as defined by ITU T.416, to the style of s
+func (s *StyledText) applySubParams(subParams []string, config *parseConfig) error {
+	param := stripLeadingZeros(subParams[0])
+	switch param {
+	case "4":
+		// Underline style
+		style, err := strconv.Atoi(stripLeadingZeros(subParams[1]))
+		if err != nil || style < int(UnderlineNone) || style > int(UnderlineDashed) {
+			return ErrInvalid
+		}
+		s.setUnderline(UnderlineStyle(style))
+	case "38", "48":
+		col, mode, err := parseSubColour(subParams[1:], config.colours.cols)
+		if err != nil {
+			return err
+		}
+		if param == "38" {
+			s.FgCol = col
+			s.FgMode = mode
+			return nil
+		}
+		s.BgCol = col
+		s.BgMode = mode
+	case "58":
+		col, _, err := parseSubColour(subParams[1:], config.colours.cols)
+		if err != nil {
+			return err
+		}
+		s.UlCol = col
+	default:
+		// Unexpected codes may be ignored.
+		if !config.ignoreUnexpectedCodes {
+			return ErrInvalid
+		}
+	}
+	return nil
+}
+
+// setUnderline sets the underline style of s, keeping
+// the Underlined style in step
+func (s *StyledText) setUnderline(style UnderlineStyle) {
+	s.Underline = style
+	if style == UnderlineNone {
+		s.Style &^= Underlined
+		return
+	}
+	s.Style |= Underlined
+}
+
+// parseExtendedColour parses the semicolon separated parameters
+// following an extended colour code (38, 48 or 58). It returns the colour,
+// its mode and the number of parameters used.
+func parseExtendedColour(params []string, cols []*Col) (*Col, ColourMode, int, error) {
+	if len(params) < 2 {
+		return nil, Default, 0, ErrInvalid
+	}
+	switch stripLeadingZeros(params[0]) {
+	case "5":
+		col, err := parse256Colour(params[1], cols)
+		return col, TwoFiveSix, 2, err
+	case "2":
+		// we must have 3 params left
+		if len(params) < 4 {
+			return nil, Default, 0, ErrInvalidTrueColorSequence
 		}
+		col, err := parseTrueColour(params[1], params[2], params[3])
+		return col, TrueColour, 4, err
+	}
+	return nil, Default, 0, ErrInvalidTrueColorSequence
+}
+
+// parseSubColour parses the colon separated sub-parameters following
+// an extended colour code (38, 48 or 58). A true colour may include the
+// colour space ID, eg. 38:2::255:0:0, or leave it out, eg. 38:2:255:0:0.
+func parseSubColour(subParams []string, cols []*Col) (*Col, ColourMode, error) {
+	switch stripLeadingZeros(subParams[0]) {
+	case "5":
+		if len(subParams) != 2 {
+			return nil, Default, ErrInvalid256ColSequence
+		}
+		col, err := parse256Colour(subParams[1], cols)
+		return col, TwoFiveSix, err
+	case "2":
+		rgb := subParams[1:]
+		if len(rgb) > 3 {
+			// Skip the colour space ID
+			rgb = rgb[1:]
+		}
+		if len(rgb) < 3 {
+			return nil, Default, ErrInvalidTrueColorSequence
+		}
+		col, err := parseTrueColour(rgb[0], rgb[1], rgb[2])
+		return col, TrueColour, err
+	}
+	return nil, Default, ErrInvalidTrueColorSequence
+}
+
+// parse256Colour returns the colour for a 256 colour index
+func parse256Colour(param string, cols []*Col) (*Col, error) {
+	colIndex, err := strconv.Atoi(stripLeadingZeros(param))
+	if err != nil {
+		return nil, ErrInvalid256ColSequence
+	}
+	if colIndex < 0 || colIndex > 255 {
+		return nil, ErrInvalid256ColSequence
+	}
+	return cols[colIndex], nil
+}
+
+// parseTrueColour returns the colour for the given red, green and
+// blue values
+func parseTrueColour(red, green, blue string) (*Col, error) {
+	ri, err := strconv.Atoi(red)
+	if err != nil {
+		return nil, ErrInvalidTrueColorSequence
+	}
+	gi, err := strconv.Atoi(green)
+	if err != nil {
+		return nil, ErrInvalidTrueColorSequence
+	}
+	bi, err := strconv.Atoi(blue)
+	if err != nil {
+		return nil, ErrInvalidTrueColorSequence
+	}
+	if bi > 255 || gi > 255 || ri > 255 {
+		return nil, ErrInvalidTrueColorSequence
+	}
+	if bi < 0 || gi < 0 || ri < 0 {
+		return nil, ErrInvalidTrueColorSequence
+	}
+	r := uint8(ri)
+	g := uint8(gi)
+	b := uint8(bi)
+	hex := [7]byte{
+		'#',
+		hexDigits[r>>4], hexDigits[r&0xf],
+		hexDigits[g>>4], hexDigits[g&0xf],
+		hexDigits[b>>4], hexDigits[b&0xf],
+	}
+	return &Col{Id: 256, Hex: string(hex[:]), Rgb: Rgb{r, g, b}}, nil
+}
+
+// hexDigits are the digits used for hex colour values
+const hexDigits = "0123456789abcdef"
+
+// maxParams is the number of SGR parameters that can be split
+// without allocating
+const maxParams = 16
+
+// splitParams splits s at each sep, appending the parts to dst.
+// Passing a slice of a local array as dst avoids allocating.
+func splitParams(s string, sep byte, dst []string) []string {
+	for {
+		index := strings.IndexByte(s, sep)
+		if index == -1 {
+			return append(dst, s)
+		}
+		dst = append(dst, s[:index])
+		s = s[index+1:]
 	}
 }
 
@@ -481,25 +847,44 @@ func HasEscapeCodes(input string) bool {
 }
 
 // String builds an ANSI string for specified StyledText slice.
-func String(input []*StyledText) string {
+func String(input []*StyledText, options ...StringOption) string {
+	colon := useColonSeparators(options)
+	if useMinimalEncoding(options) {
+		encoder := minimalEncoder{colon: colon, config: newParseConfig(nil)}
+		for _, text := range input {
+			encoder.write(text)
+		}
+		encoder.close()
+		return encoder.result.String()
+	}
 	var result strings.Builder
 	for _, text := range input {
-		params := text.styleToParams()
+		params := text.styleToParams(colon)
 		if len(params) == 0 {
-			result.WriteString(text.Label)
+			result.WriteString(text.linkStart() + text.Label + text.linkEnd())
 			continue
 		}
-		result.WriteString(text.String())
+		result.WriteString(text.string(colon))
 	}
 	return result.String()
 }
 
 // Truncate truncates text to length but preserves control symbols in ANSI string.
 func Truncate(input string, maxChars int, options ...ParseOption) (string, error) {
-	parsed, err := Parse(input, options...)
+	truncated, err := truncate(input, maxChars, options)
 	if err != nil {
 		return "", err
 	}
+	return String(truncated), nil
+}
+
+// truncate parses input and truncates the text to maxChars
+// user-perceived characters
+func truncate(input string, maxChars int, options []ParseOption) ([]*StyledText, error) {
+	parsed, err := Parse(input, options...)
+	if err != nil {
+		return nil, err
+	}
 	charsLeft := maxChars
 	var result []*StyledText
 	for _, element := range parsed {
@@ -513,14 +898,14 @@ func Truncate(input string, maxChars int, options ...ParseOption) (string, error
 				if charsLeft == 0 {
 					element.Label = string(newLabel)
 					result = append(result, element)
-					return String(result), nil
+					return result, nil
 				}
 			}
 		}
 		result = append(result, element)
 		charsLeft -= userPerceivedChars
 	}
-	return String(result), nil
+	return result, nil
 }
 
 // Cleanse removes ANSI control symbols from the string.
@@ -528,15 +913,23 @@ func Cleanse(input string, options ...ParseOption) (string, error) {
 	if input == "" {
 		return "", nil
 	}
-	parsed, err := Parse(input, options...)
+	result, err := appendCleanse(nil, input, options)
 	if err != nil {
 		return "", err
 	}
-	var result strings.Builder
-	for _, element := range parsed {
-		result.WriteString(element.Label)
-	}
-	return result.String(), nil
+	return string(result), nil
+}
+
+// appendCleanse appends the text of input, without the
+// ANSI control symbols, to dst
+func appendCleanse(dst []byte, input string, options []ParseOption) ([]byte, error) {
+	var state StyledText
+	config := newParseConfig(options)
+	err := each(input, &state, &config, func(text StyledText) bool {
+		dst = append(dst, text.Label...)
+		return true
+	})
+	return dst, err
 }
 
 // Length calculates count of user-perceived characters in ANSI string.
@@ -544,14 +937,15 @@ func Length(input string, options ...ParseOption) (int, error) {
 	if input == "" {
 		return 0, nil
 	}
-	parsed, err := Parse(input, options...)
+	var state StyledText
+	config := newParseConfig(options)
+	result := 0
+	err := each(input, &state, &config, func(text StyledText) bool {
+		result += uniseg.GraphemeClusterCount(text.Label)
+		return true
+	})
 	if err != nil {
 		return -1, err
 	}
-	var result int
-	for _, element := range parsed {
-		userPerceivedChars := uniseg.GraphemeClusterCount(element.Label)
-		result += userPerceivedChars
-	}
 	return result, nil
 }
@@ -317,9 +317,9 @@ func TestParseAnsi256(t *testing.T) {
 		{"Grey93 Bold & DarkViolet Italic", "\u001B[0;1;38;5;256mGrey93\u001B[0m", nil, true},
 		{"Grey93 Bold & DarkViolet Italic", "\u001B[0;1;38;5;-1mGrey93\u001B[0m", nil, true},
 		{"Bad No of Params", "\u001B[0;1;38;5mGrey93\u001B[0m", nil, true},
-		{"Bad Params", "\u001B[0;1;38;fivemGrey93\u001B[0m", nil, true},
+		{"Bad Params", "\u001B[0;1;38;?mGrey93\u001B[0m", nil, true},
 		{"Bad Params 2", "\u001B[0;1;38;3mGrey93\u001B[0m", nil, true},
-		{"Bad Params 3", "\u001B[0;1;38;5;fivemGrey93\u001B[0m", nil, true},
+		{"Bad Params 3", "\u001B[0;1;38;5;?mGrey93\u001B[0m", nil, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -429,7 +429,7 @@ func TestParseAnsiBG256(t *testing.T) {
 		{"Grey93 Bold & DarkViolet Italic", "\u001B[0;1;48;5;256mGrey93\u001B[0m", nil, true},
 		{"Grey93 Bold & DarkViolet Italic", "\u001B[0;1;48;5;-1mGrey93\u001B[0m", nil, true},
 		{"Bad No of Params", "\u001B[0;1;48;5mGrey93\u001B[0m", nil, true},
-		{"Bad Params", "\u001B[0;1;48;fivemGrey93\u001B[0m", nil, true},
+		{"Bad Params", "\u001B[0;1;48;?mGrey93\u001B[0m", nil, true},
 		{"Bad Params 2", "\u001B[0;1;48;3mGrey93\u001B[0m", nil, true},
 		{"Bad Params 2", "\u001B[0;1;50;3mGrey93\u001B[0m", nil, true},
 	}
@@ -470,11 +470,11 @@ func TestParseAnsiTrueColor(t *testing.T) {
 		{"Bad 1", "\u001B[38;2;256;0;0mRed\u001B[0m", nil, true},
 		{"Bad 2", "\u001B[38;2;-1;0;0mRed\u001B[0m", nil, true},
 		{"Bad no of params", "\u001B[38;2;0;0mRed\u001B[0m", nil, true},
-		{"Bad params", "\u001B[38;2;0;onemRed\u001B[0m", nil, true},
-		{"Bad params 2", "\u001B[38;2;red;0;0mRed\u001B[0m", nil, true},
-		{"Bad params 3", "\u001B[38;2;0;red;0mRed\u001B[0m", nil, true},
-		{"Bad params 4", "\u001B[38;2;0;0;redmRed\u001B[0m", nil, true},
-		{"Bad params 4", "\u001B[38;3;0;0;redmRed\u001B[0m", nil, true},
+		{"Bad params", "\u001B[38;2;0;?mRed\u001B[0m", nil, true},
+		{"Bad params 2", "\u001B[38;2;?;0;0mRed\u001B[0m", nil, true},
+		{"Bad params 3", "\u001B[38;2;0;?;0mRed\u001B[0m", nil, true},
+		{"Bad params 4", "\u001B[38;2;0;0;?mRed\u001B[0m", nil, true},
+		{"Bad params 4", "\u001B[38;3;0;0;?mRed\u001B[0m", nil, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -516,7 +516,7 @@ func TestParseAnsiWithOptions(t *testing.T) {
 		},
 		{
 			"Foreground code default", "\u001b[0;39mHello World\033[0m", nil,
-			[]*StyledText{{Label: "Hello World", FgCol: Cols[7]}}, false,
+			[]*StyledText{{Label: "Hello World"}}, false,
 		},
 		{
 			"Foreground code specified", "\u001b[0;39mHello World\033[0m",
@@ -525,7 +525,15 @@ func TestParseAnsiWithOptions(t *testing.T) {
 		},
 		{
 			"Background code default", "\u001b[0;49mHello World\033[0m", nil,
-			[]*StyledText{{Label: "Hello World", BgCol: Cols[0]}}, false,
+			[]*StyledText{{Label: "Hello World"}}, false,
+		},
+		{
+			"Colours reset to default", "\u001b[31;42mRed\033[39mGreen\033[49mPlain", nil,
+			[]*StyledText{
+				{Label: "Red", FgCol: Cols[1], BgCol: Cols[2]},
+				{Label: "Green", BgCol: Cols[2]},
+				{Label: "Plain"},
+			}, false,
 		},
 		{
 			"Background code specified", "\u001b[0;49mHello World\033[0m",
@@ -539,6 +547,8 @@ func TestParseAnsiWithOptions(t *testing.T) {
 			is2.Equal(err != nil, tt.wantErr)
 			for index, w := range tt.want {
 				is2.Equal(got[index].Label, w.Label)
+				is2.Equal(got[index].FgCol == nil, w.FgCol == nil)
+				is2.Equal(got[index].BgCol == nil, w.BgCol == nil)
 				if w.FgCol != nil {
 					is2.Equal(got[index].FgCol.Name, w.FgCol.Name)
 				}
@@ -578,9 +588,12 @@ func TestString(t *testing.T) {
 	}{
 		{"Blank", []*StyledText{}, ""},
 		{"ANSI16 Fg", []*StyledText{{Label: "Red", FgCol: Cols[1]}}, "\033[0;31mRed\033[0m"},
-		{"ANSI16 Fg Bold", []*StyledText{{Label: "Red", FgCol: Cols[1], Style: Bold}}, "\033[0;1;31mRed\033[0m"},
+		{"ANSI16 Fg Bold", []*StyledText{{Label: "Red", FgCol: Cols[1], Style: Bold}}, "\033[0;31;1mRed\033[0m"},
+		{"ANSI16 Bright Fg Bold", []*StyledText{{Label: "Red", FgCol: Cols[9], Style: Bold}}, "\033[0;1;31mRed\033[0m"},
+		{"ANSI16 Bright Fg Not Bold", []*StyledText{{Label: "Red", FgCol: Cols[9]}}, "\033[0;38;5;9mRed\033[0m"},
+		{"ANSI16 Bright Bg", []*StyledText{{Label: "Red", FgCol: Cols[1], BgCol: Cols[9], Style: Bright}}, "\033[0;31;101mRed\033[0m"},
 		{"ANSI16 Fg Strikethrough", []*StyledText{{Label: "Red", FgCol: Cols[1], Style: Strikethrough}}, "\033[0;9;31mRed\033[0m"},
-		{"ANSI16 Fg Bold & Italic", []*StyledText{{Label: "Red", FgCol: Cols[1], Style: Bold | Italic}}, "\033[0;1;3;31mRed\033[0m"},
+		{"ANSI16 Fg Bold & Italic", []*StyledText{{Label: "Red", FgCol: Cols[1], Style: Bold | Italic}}, "\033[0;3;31;1mRed\033[0m"},
 		{"ANSI16 Bg", []*StyledText{{Label: "Black", BgCol: Cols[0]}}, "\033[0;40mBlack\033[0m"},
 		{"ANSI16 Mixed", []*StyledText{{Label: "Mixed", FgCol: Cols[1], BgCol: Cols[0]}}, "\033[0;31;40mMixed\033[0m"},
 		{"ANSI256 Fg", []*StyledText{{ColourMode: TwoFiveSix, Label: "Dark Blue", FgCol: Cols[18]}}, "\033[0;38;5;18mDark Blue\033[0m"},
@@ -709,3 +722,618 @@ func TestStripLeadingZeros(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTrueColour(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name    string
+		rgb     [3]string
+		wantErr bool
+	}{
+		{"Valid", [3]string{"1", "2", "3"}, false},
+		{"Not a number", [3]string{"1", "x", "3"}, true},
+		{"Too large", [3]string{"1", "2", "256"}, true},
+		{"Negative", [3]string{"-1", "2", "3"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			col, err := parseTrueColour(tt.rgb[0], tt.rgb[1], tt.rgb[2])
+			is2.Equal(err != nil, tt.wantErr)
+			if !tt.wantErr {
+				is2.Equal(col.Hex, "#010203")
+			}
+		})
+	}
+}
+
+func TestParseControlSequences(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name    string
+		input   string
+		want    []*StyledText
+		wantErr bool
+	}{
+		{"Erase line", "\033[2Kdone \033[0m", []*StyledText{
+			{Label: "done ", Offset: 0, Len: 9},
+		}, false},
+		{"Cursor movement", "\033[31m\033[1Amove\033[10;20Hd", []*StyledText{
+			{Label: "move", FgCol: &Col{Name: "Maroon"}, Offset: 0, Len: 13},
+			{Label: "d", FgCol: &Col{Name: "Maroon"}, Offset: 13, Len: 9},
+		}, false},
+		{"Scroll region", "\033[1;24rscrolled", []*StyledText{
+			{Label: "scrolled", Offset: 0, Len: 15},
+		}, false},
+		{"Private mode", "\033[?25l\033[1mhidden\033[?25h", []*StyledText{
+			{Label: "hidden", Style: Bold, Offset: 0, Len: 16},
+		}, false},
+		{"Private m", "\033[>4;2mtext", []*StyledText{
+			{Label: "text", Offset: 0, Len: 11},
+		}, false},
+		{"Intermediate", "\033[0 qtext", []*StyledText{
+			{Label: "text", Offset: 0, Len: 9},
+		}, false},
+		{"Missing terminator", "\033[2", nil, true},
+		{"Bad parameter byte", "\033[1 2Ktext", nil, true},
+		{"Control character", "\033[1\nmtext", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			is2.Equal(err != nil, tt.wantErr)
+			is2.Equal(len(got), len(tt.want))
+			for index, w := range tt.want {
+				is2.Equal(got[index].Label, w.Label)
+				if w.FgCol != nil {
+					is2.Equal(got[index].FgCol.Name, w.FgCol.Name)
+				}
+				is2.Equal(got[index].Style, w.Style)
+				is2.Equal(got[index].Offset, w.Offset)
+				is2.Equal(got[index].Len, w.Len)
+			}
+		})
+	}
+}
+
+func TestParseHyperlinks(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name    string
+		input   string
+		want    []*StyledText
+		wantErr bool
+	}{
+		{"BEL terminated", "\033]8;;https://example.com\aExample\033]8;;\a", []*StyledText{
+			{Label: "Example", Hyperlink: &Hyperlink{URL: "https://example.com"}},
+		}, false},
+		{"ST terminated", "\033]8;;https://example.com\033\\Example\033]8;;\033\\", []*StyledText{
+			{Label: "Example", Hyperlink: &Hyperlink{URL: "https://example.com"}},
+		}, false},
+		{"With id", "See \033]8;id=link1:x=y;https://example.com/a;b\033\\\033[1mhere\033[0m\033]8;;\033\\ now", []*StyledText{
+			{Label: "See "},
+			{Label: "here", Style: Bold, Hyperlink: &Hyperlink{URL: "https://example.com/a;b", ID: "link1"}},
+			{Label: " now"},
+		}, false},
+		{"Style inside link", "\033]8;;http://a\a\033[31mred\033[0mplain\033]8;;\a", []*StyledText{
+			{Label: "red", FgCol: &Col{Name: "Maroon"}, Hyperlink: &Hyperlink{URL: "http://a"}},
+			{Label: "plain", Hyperlink: &Hyperlink{URL: "http://a"}},
+		}, false},
+		{"Other commands skipped", "\033]0;title\atext", []*StyledText{
+			{Label: "text"},
+		}, false},
+		{"Link without parameters", "\033]8\033\\text", []*StyledText{
+			{Label: "text"},
+		}, false},
+		{"Missing terminator", "\033]8;;https://example.com", nil, true},
+		{"Bad terminator", "\033]8;;https://example.com\033[0mtext", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			is2.Equal(err != nil, tt.wantErr)
+			is2.Equal(len(got), len(tt.want))
+			for index, w := range tt.want {
+				is2.Equal(got[index].Label, w.Label)
+				if w.FgCol != nil {
+					is2.Equal(got[index].FgCol.Name, w.FgCol.Name)
+				}
+				is2.Equal(got[index].Style, w.Style)
+				is2.Equal(got[index].Hyperlink, w.Hyperlink)
+			}
+		})
+	}
+}
+
+func TestRoundtripHyperlinks(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Plain", "\033]8;;https://example.com\033\\Example\033]8;;\033\\"},
+		{"With id", "\033]8;id=1;https://example.com\033\\Example\033]8;;\033\\"},
+		{"Styled", "text \033]8;;https://example.com\033\\\033[0;1;31mExample\033[0m\033]8;;\033\\"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			is2.NoErr(err)
+			output := String(got)
+			is2.Equal(output, tt.input)
+		})
+	}
+}
+
+func TestParseSubParameters(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name    string
+		input   string
+		want    []*StyledText
+		wantErr bool
+	}{
+		{"TrueColor with colour space", "\033[38:2::255:0:0mRed\033[0m", []*StyledText{
+			{Label: "Red", FgCol: &Col{Rgb: Rgb{255, 0, 0}, Hex: "#ff0000"}, FgMode: TrueColour, ColourMode: TrueColour},
+		}, false},
+		{"TrueColor with colour space ID", "\033[48:2:1:0:255:0mGreen\033[0m", []*StyledText{
+			{Label: "Green", BgCol: &Col{Rgb: Rgb{0, 255, 0}, Hex: "#00ff00"}, BgMode: TrueColour, ColourMode: TrueColour},
+		}, false},
+		{"TrueColor without colour space", "\033[38:2:0:0:255mBlue\033[0m", []*StyledText{
+			{Label: "Blue", FgCol: &Col{Rgb: Rgb{0, 0, 255}, Hex: "#0000ff"}, FgMode: TrueColour, ColourMode: TrueColour},
+		}, false},
+		{"256 colours", "\033[1;38:5:208mOrange\033[0m", []*StyledText{
+			{Label: "Orange", FgCol: Cols[208], Style: Bold, FgMode: TwoFiveSix, ColourMode: TwoFiveSix},
+		}, false},
+		{"256 colours BG", "\033[48:5:18;31mText\033[0m", []*StyledText{
+			{Label: "Text", FgCol: Cols[1], BgCol: Cols[18], BgMode: TwoFiveSix, ColourMode: TwoFiveSix},
+		}, false},
+		{"Curly underline", "\033[4:3mCurly\033[4:0mPlain", []*StyledText{
+			{Label: "Curly", Style: Underlined},
+			{Label: "Plain"},
+		}, false},
+		{"Bad 256 index", "\033[38:5:256mText", nil, true},
+		{"Bad 256 params", "\033[38:5:1:2mText", nil, true},
+		{"Bad TrueColor", "\033[38:2::256:0:0mText", nil, true},
+		{"Short TrueColor", "\033[38:2:0:0mText", nil, true},
+		{"Bad colour mode", "\033[38:3:0mText", nil, true},
+		{"Bad underline", "\033[4:9mText", nil, true},
+		{"Unexpected", "\033[1:2mText", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			is2.Equal(err != nil, tt.wantErr)
+			is2.Equal(len(got), len(tt.want))
+			for index, w := range tt.want {
+				is2.Equal(got[index].Label, w.Label)
+				if w.FgCol != nil {
+					is2.Equal(got[index].FgCol.Hex, w.FgCol.Hex)
+					is2.Equal(got[index].FgCol.Rgb, w.FgCol.Rgb)
+				}
+				if w.BgCol != nil {
+					is2.Equal(got[index].BgCol.Hex, w.BgCol.Hex)
+					is2.Equal(got[index].BgCol.Rgb, w.BgCol.Rgb)
+				}
+				is2.Equal(got[index].Style, w.Style)
+				is2.Equal(got[index].ColourMode, w.ColourMode)
+				is2.Equal(got[index].FgMode, w.FgMode)
+				is2.Equal(got[index].BgMode, w.BgMode)
+			}
+		})
+	}
+}
+
+func TestStringWithColonSeparators(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input []*StyledText
+		want  string
+	}{
+		{"ANSI16 Fg", []*StyledText{{Label: "Red", FgCol: Cols[1]}}, "\033[0;31mRed\033[0m"},
+		{"ANSI256 Fg", []*StyledText{{ColourMode: TwoFiveSix, Label: "Orange", FgCol: Cols[208], Style: Bold}}, "\033[0;1;38:5:208mOrange\033[0m"},
+		{"ANSI256 Bg", []*StyledText{{ColourMode: TwoFiveSix, Label: "Dark Blue", BgCol: Cols[18]}}, "\033[0;48:5:18mDark Blue\033[0m"},
+		{"Truecolor Mixed", []*StyledText{{ColourMode: TrueColour, Label: "TrueColor!", FgCol: &Col{Id: 256, Rgb: Rgb{R: 90, G: 91, B: 92}}, BgCol: &Col{Id: 256, Rgb: Rgb{R: 128, G: 127, B: 126}}}}, "\033[0;38:2::90:91:92;48:2::128:127:126mTrueColor!\033[0m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := String(tt.input, WithColonSeparators())
+			is2.Equal(got, tt.want)
+			// The colon form parses back to the same colours
+			parsed, err := Parse(got)
+			is2.NoErr(err)
+			is2.Equal(String(parsed), String(tt.input))
+		})
+	}
+}
+
+func TestParseAttributesOff(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+		want  []*StyledText
+	}{
+		{"Normal intensity", "\033[1mA\033[22mB", []*StyledText{
+			{Label: "A", Style: Bold},
+			{Label: "B"},
+		}},
+		{"Normal intensity clears faint", "\033[1;2;3mA\033[22mB", []*StyledText{
+			{Label: "A", Style: Bold | Faint | Italic},
+			{Label: "B", Style: Italic},
+		}},
+		{"Normal intensity colours", "\033[1;22;31mRed", []*StyledText{
+			{Label: "Red", FgCol: &Col{Name: "Maroon"}},
+		}},
+		{"Double underline", "\033[21mA\033[24mB", []*StyledText{
+			{Label: "A", Style: Underlined},
+			{Label: "B"},
+		}},
+		{"Not italic", "\033[3;4mA\033[23mB", []*StyledText{
+			{Label: "A", Style: Italic | Underlined},
+			{Label: "B", Style: Underlined},
+		}},
+		{"Not underlined", "\033[4;9mA\033[24mB", []*StyledText{
+			{Label: "A", Style: Underlined | Strikethrough},
+			{Label: "B", Style: Strikethrough},
+		}},
+		{"Not blinking", "\033[5;1mA\033[25mB", []*StyledText{
+			{Label: "A", Style: Blinking | Bold},
+			{Label: "B", Style: Bold},
+		}},
+		{"Not inversed", "\033[7;8mA\033[27mB", []*StyledText{
+			{Label: "A", Style: Inversed | Invisible},
+			{Label: "B", Style: Invisible},
+		}},
+		{"Not invisible", "\033[8;7mA\033[28mB", []*StyledText{
+			{Label: "A", Style: Invisible | Inversed},
+			{Label: "B", Style: Inversed},
+		}},
+		{"Not strikethrough", "\033[9;3mA\033[29mB", []*StyledText{
+			{Label: "A", Style: Strikethrough | Italic},
+			{Label: "B", Style: Italic},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			is2.NoErr(err)
+			is2.Equal(len(got), len(tt.want))
+			for index, w := range tt.want {
+				is2.Equal(got[index].Label, w.Label)
+				if w.FgCol != nil {
+					is2.Equal(got[index].FgCol.Name, w.FgCol.Name)
+				}
+				is2.Equal(got[index].Style, w.Style)
+			}
+		})
+	}
+}
+
+func TestParseUnderlineStyles(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name    string
+		input   string
+		want    []*StyledText
+		wantErr bool
+	}{
+		{"Single", "\033[4mA", []*StyledText{
+			{Label: "A", Style: Underlined, Underline: UnderlineSingle},
+		}, false},
+		{"Double", "\033[21mA\033[4:2mB", []*StyledText{
+			{Label: "A", Style: Underlined, Underline: UnderlineDouble},
+			{Label: "B", Style: Underlined, Underline: UnderlineDouble},
+		}, false},
+		{"Curly, dotted & dashed", "\033[4:3mA\033[4:4mB\033[4:5mC\033[4:0mD", []*StyledText{
+			{Label: "A", Style: Underlined, Underline: UnderlineCurly},
+			{Label: "B", Style: Underlined, Underline: UnderlineDotted},
+			{Label: "C", Style: Underlined, Underline: UnderlineDashed},
+			{Label: "D"},
+		}, false},
+		{"Underline colour", "\033[4;58;5;208mA\033[58;2;1;2;3mB\033[59mC", []*StyledText{
+			{Label: "A", Style: Underlined, Underline: UnderlineSingle, UlCol: Cols[208]},
+			{Label: "B", Style: Underlined, Underline: UnderlineSingle, UlCol: &Col{Id: 256, Hex: "#010203", Rgb: Rgb{1, 2, 3}}},
+			{Label: "C", Style: Underlined, Underline: UnderlineSingle},
+		}, false},
+		{"Underline colour sub-parameters", "\033[4:3;58:2::255:0:0mA\033[58:5:9mB\033[0mC", []*StyledText{
+			{Label: "A", Style: Underlined, Underline: UnderlineCurly, UlCol: &Col{Id: 256, Hex: "#ff0000", Rgb: Rgb{255, 0, 0}}},
+			{Label: "B", Style: Underlined, Underline: UnderlineCurly, UlCol: Cols[9]},
+			{Label: "C"},
+		}, false},
+		{"Not underlined keeps colour", "\033[4;58;5;1mA\033[24mB", []*StyledText{
+			{Label: "A", Style: Underlined, Underline: UnderlineSingle, UlCol: Cols[1]},
+			{Label: "B", UlCol: Cols[1]},
+		}, false},
+		{"Bad underline colour", "\033[58;5;256mA", nil, true},
+		{"Bad underline colour sub-parameters", "\033[58:2::1:2mA", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			is2.Equal(err != nil, tt.wantErr)
+			is2.Equal(len(got), len(tt.want))
+			for index, w := range tt.want {
+				is2.Equal(got[index].Label, w.Label)
+				is2.Equal(got[index].Style, w.Style)
+				is2.Equal(got[index].Underline, w.Underline)
+				is2.Equal(got[index].UlCol, w.UlCol)
+			}
+		})
+	}
+}
+
+func TestStringUnderlineStyles(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name      string
+		input     []*StyledText
+		want      string
+		wantColon string
+	}{
+		{"Underlined", []*StyledText{{Label: "A", Style: Underlined}}, "\033[0;4mA\033[0m", "\033[0;4mA\033[0m"},
+		{"Single", []*StyledText{{Label: "A", Style: Underlined, Underline: UnderlineSingle}}, "\033[0;4mA\033[0m", "\033[0;4mA\033[0m"},
+		{"Double", []*StyledText{{Label: "A", Style: Underlined, Underline: UnderlineDouble}}, "\033[0;21mA\033[0m", "\033[0;4:2mA\033[0m"},
+		{"Curly", []*StyledText{{Label: "A", Style: Underlined, Underline: UnderlineCurly}}, "\033[0;4:3mA\033[0m", "\033[0;4:3mA\033[0m"},
+		{"Dashed & colour", []*StyledText{{Label: "A", Style: Underlined, Underline: UnderlineDashed, UlCol: Cols[208]}}, "\033[0;4:5;58;5;208mA\033[0m", "\033[0;4:5;58:5:208mA\033[0m"},
+		{"Truecolor", []*StyledText{{Label: "A", Style: Underlined, UlCol: &Col{Id: 256, Rgb: Rgb{1, 2, 3}}}}, "\033[0;4;58;2;1;2;3mA\033[0m", "\033[0;4;58:2::1:2:3mA\033[0m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is2.Equal(String(tt.input), tt.want)
+			is2.Equal(String(tt.input, WithColonSeparators()), tt.wantColon)
+			// Both forms parse back to the same style
+			for _, output := range []string{tt.want, tt.wantColon} {
+				parsed, err := Parse(output)
+				is2.NoErr(err)
+				is2.Equal(String(parsed), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExtendedAttributes(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+		want  []*StyledText
+	}{
+		{"Rapid blinking", "\033[6mA\033[25mB", []*StyledText{
+			{Label: "A", Style: RapidBlinking},
+			{Label: "B"},
+		}},
+		{"Fonts", "\033[11mA\033[19mB\033[10mC", []*StyledText{
+			{Label: "A", Font: 1},
+			{Label: "B", Font: 9},
+			{Label: "C"},
+		}},
+		{"Fraktur", "\033[3;20mA\033[23mB", []*StyledText{
+			{Label: "A", Style: Italic | Fraktur},
+			{Label: "B"},
+		}},
+		{"Framed & encircled", "\033[51mA\033[52mB\033[54mC", []*StyledText{
+			{Label: "A", Style: Framed},
+			{Label: "B", Style: Framed | Encircled},
+			{Label: "C"},
+		}},
+		{"Overlined", "\033[53;4mA\033[55mB", []*StyledText{
+			{Label: "A", Style: Overlined | Underlined, Underline: UnderlineSingle},
+			{Label: "B", Style: Underlined, Underline: UnderlineSingle},
+		}},
+		{"Superscript & subscript", "\033[73mA\033[74mB\033[75mC", []*StyledText{
+			{Label: "A", Style: Superscript},
+			{Label: "B", Style: Subscript},
+			{Label: "C"},
+		}},
+		{"Reset", "\033[6;12;20;51;52;53;73mA\033[0mB", []*StyledText{
+			{Label: "A", Style: RapidBlinking | Fraktur | Framed | Encircled | Overlined | Superscript, Font: 2},
+			{Label: "B"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			is2.NoErr(err)
+			is2.Equal(len(got), len(tt.want))
+			for index, w := range tt.want {
+				is2.Equal(got[index].Label, w.Label)
+				is2.Equal(got[index].Style, w.Style)
+				is2.Equal(got[index].Underline, w.Underline)
+				is2.Equal(got[index].Font, w.Font)
+			}
+		})
+	}
+	// Accessors
+	got, err := Parse("\033[6;20;51;52;53;73mA\033[74mB")
+	is2.NoErr(err)
+	is2.True(got[0].RapidBlinking())
+	is2.True(got[0].Fraktur())
+	is2.True(got[0].Framed())
+	is2.True(got[0].Encircled())
+	is2.True(got[0].Overlined())
+	is2.True(got[0].Superscript())
+	is2.True(!got[0].Subscript())
+	is2.True(got[1].Subscript())
+	is2.True(!got[1].Superscript())
+}
+
+func TestRoundtripExtendedAttributes(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Rapid blinking", "\033[0;6mA\033[0m"},
+		{"Font", "\033[0;1;13mA\033[0m"},
+		{"Fraktur", "\033[0;20mA\033[0m"},
+		{"Framed", "\033[0;51mA\033[0m"},
+		{"Encircled", "\033[0;52mA\033[0m"},
+		{"Overlined", "\033[0;53mA\033[0m"},
+		{"Superscript", "\033[0;73mA\033[0m"},
+		{"Subscript & colour", "\033[0;74;31mA\033[0m"},
+		{"Everything", "\033[0;1;3;4;6;9;15;20;51;52;53;73;38;5;208mA\033[0m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			is2.NoErr(err)
+			is2.Equal(String(got), tt.input)
+		})
+	}
+}
+
+func TestParseColourModes(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+		want  []*StyledText
+	}{
+		{"16 Fg & TrueColor Bg", "\033[31;48;2;10;20;30mA", []*StyledText{
+			{Label: "A", FgCol: Cols[1], BgCol: &Col{Id: 256, Hex: "#0a141e", Rgb: Rgb{10, 20, 30}}, FgMode: Default, BgMode: TrueColour, ColourMode: TrueColour},
+		}},
+		{"TrueColor Fg & 16 Bg", "\033[38;2;10;20;30;41mA", []*StyledText{
+			{Label: "A", FgCol: &Col{Id: 256, Hex: "#0a141e", Rgb: Rgb{10, 20, 30}}, BgCol: Cols[1], FgMode: TrueColour, BgMode: Default, ColourMode: TrueColour},
+		}},
+		{"Carried into next segment", "\033[38;5;208mA\033[48;5;18mB\033[31mC\033[49mD", []*StyledText{
+			{Label: "A", FgCol: Cols[208], FgMode: TwoFiveSix, ColourMode: TwoFiveSix},
+			{Label: "B", FgCol: Cols[208], BgCol: Cols[18], FgMode: TwoFiveSix, BgMode: TwoFiveSix, ColourMode: TwoFiveSix},
+			{Label: "C", FgCol: Cols[1], BgCol: Cols[18], FgMode: Default, BgMode: TwoFiveSix, ColourMode: TwoFiveSix},
+			{Label: "D", FgCol: Cols[1], FgMode: Default},
+		}},
+		{"Reset", "\033[38;5;208;48;2;1;2;3mA\033[0mB", []*StyledText{
+			{Label: "A", FgCol: Cols[208], BgCol: &Col{Id: 256, Hex: "#010203", Rgb: Rgb{1, 2, 3}}, FgMode: TwoFiveSix, BgMode: TrueColour},
+			{Label: "B"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			is2.NoErr(err)
+			is2.Equal(len(got), len(tt.want))
+			for index, w := range tt.want {
+				is2.Equal(got[index].Label, w.Label)
+				is2.Equal(got[index].FgCol, w.FgCol)
+				is2.Equal(got[index].BgCol, w.BgCol)
+				is2.Equal(got[index].FgMode, w.FgMode)
+				is2.Equal(got[index].BgMode, w.BgMode)
+				is2.Equal(got[index].ColourMode, w.ColourMode)
+			}
+		})
+	}
+}
+
+func TestParseIntensityColours(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name   string
+		input  string
+		fgCols []*Col
+		bgCols []*Col
+	}{
+		{"Bold first", "\033[1;31;41mA", []*Col{Cols[9]}, []*Col{Cols[9]}},
+		{"Colour first", "\033[31;41;1mA", []*Col{Cols[1]}, []*Col{Cols[1]}},
+		{"Bold in earlier sequence", "\033[1m\033[31;41mA", []*Col{Cols[9]}, []*Col{Cols[9]}},
+		{"Bold in earlier text", "\033[1mA\033[32mB", []*Col{nil, Cols[10]}, []*Col{nil, nil}},
+		{"Faint", "\033[2m\033[31mA", []*Col{Cols[1]}, []*Col{nil}},
+		{"Bold and faint", "\033[1;2m\033[31mA\033[2;1;32mB", []*Col{Cols[9], Cols[10]}, []*Col{nil, nil}},
+		{"Reset", "\033[1m\033[0;31mA", []*Col{Cols[1]}, []*Col{nil}},
+		{"Normal intensity", "\033[1;31;41mA\033[22mB", []*Col{Cols[9], Cols[1]}, []*Col{Cols[9], Cols[1]}},
+		{"Normal intensity in same sequence", "\033[1;31;22mA", []*Col{Cols[1]}, []*Col{nil}},
+		{"Normal intensity after faint", "\033[1;2;32mA\033[22mB", []*Col{Cols[10], Cols[2]}, []*Col{nil, nil}},
+		{"Normal intensity of standard colour", "\033[31;1mA\033[22mB", []*Col{Cols[1], Cols[1]}, []*Col{nil, nil}},
+		{"Normal intensity of bright code", "\033[1;91mA\033[22mB", []*Col{Cols[9], Cols[9]}, []*Col{nil, nil}},
+		{"Normal intensity of 256 colour", "\033[1;38;5;9mA\033[22mB", []*Col{Cols[9], Cols[9]}, []*Col{nil, nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			is2.NoErr(err)
+			is2.Equal(len(got), len(tt.fgCols))
+			for index := range got {
+				is2.Equal(got[index].FgCol, tt.fgCols[index])
+				is2.Equal(got[index].BgCol, tt.bgCols[index])
+			}
+		})
+	}
+}
+
+func TestRoundtripMixedColourModes(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"16 Fg & TrueColor Bg", "\033[0;31;48;2;10;20;30mA\033[0m"},
+		{"TrueColor Fg & 16 Bg", "\033[0;38;2;10;20;30;41mA\033[0m"},
+		{"256 Fg & 16 Bg", "\033[0;38;5;208;41mA\033[0m"},
+		{"16 Fg & 256 Bg", "\033[0;32;48;5;18mA\033[0m"},
+		{"256 Fg & TrueColor Bg", "\033[0;38;5;208;48;2;1;2;3mA\033[0m"},
+		{"Carried", "\033[0;38;5;208mA\033[0m\033[0;38;5;208;41mB\033[0m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			is2.NoErr(err)
+			is2.Equal(String(got), tt.input)
+		})
+	}
+}
+
+func TestParseWithRecovery(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name    string
+		input   string
+		want    []*StyledText
+		dropped []string
+	}{
+		{"Bad 256 colour", "\033[31mA\033[1;38;5;999mB", []*StyledText{
+			{Label: "A", FgCol: Cols[1], Len: 6},
+			{Label: "B", FgCol: Cols[1], Offset: 6, Len: 14},
+		}, []string{"\033[1;38;5;999m"}},
+		{"Bad true colour", "\033[38;2;300;0;0mA\033[1mB", []*StyledText{
+			{Label: "A", Len: 16},
+			{Label: "B", Style: Bold, Offset: 16, Len: 5},
+		}, []string{"\033[38;2;300;0;0m"}},
+		{"Unknown code", "\033[99mA", []*StyledText{
+			{Label: "A", Len: 6},
+		}, []string{"\033[99m"}},
+		{"Bad byte", "A\033[3\x01mB", []*StyledText{
+			{Label: "A", Len: 1},
+			{Label: "mB", Offset: 1, Len: 6},
+		}, []string{"\033[3\x01"}},
+		{"Interrupted", "\033]8;;http://a\033[1mB", []*StyledText{
+			{Label: "B", Style: Bold, Len: 18},
+		}, []string{"\033]8;;http://a"}},
+		{"Missing terminator", "\033[1mA\033[31", []*StyledText{
+			{Label: "A", Style: Bold, Len: 5},
+		}, []string{"\033[31"}},
+		{"Every problem", "\033[99mA\033[98mB\033[38;5mC", []*StyledText{
+			{Label: "A", Len: 6},
+			{Label: "B", Offset: 6, Len: 6},
+			{Label: "C", Offset: 12, Len: 8},
+		}, []string{"\033[99m", "\033[98m", "\033[38;5m"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input, WithRecovery())
+			is2.NoErr(err)
+			is2.Equal(len(got), len(tt.want))
+			for index, w := range tt.want {
+				is2.Equal(*got[index], *w)
+			}
+
+			var dropped []string
+			handler := func(err *ParseError) {
+				is2.Equal(tt.input[err.Offset:err.Offset+len(err.Sequence)], err.Sequence)
+				dropped = append(dropped, err.Sequence)
+			}
+			// Other options do not affect recovery
+			_, err = Parse(tt.input, WithBoldAsBright(false), WithRecoveryHandler(handler))
+			is2.NoErr(err)
+			is2.Equal(dropped, tt.dropped)
+		})
+	}
+}
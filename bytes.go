@@ -0,0 +1,93 @@
+package ansi
+
+import (
+	"bytes"
+	"unsafe"
+)
+
+// bytesToString returns a string that shares the memory of b,
+// so that b can be parsed without copying it
+func bytesToString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// ParseBytes is like Parse, but parses a byte slice without copying
+// it. The Labels of the returned StyledText share the memory of input,
+// so input must not be modified while they are in use. A returned
+// error does not share the memory of input.
+func ParseBytes(input []byte, options ...ParseOption) ([]*StyledText, error) {
+	result, err := Parse(bytesToString(input), options...)
+	return result, copyError(err)
+}
+
+// EachBytes is like Each, but parses a byte slice without copying it.
+// The Label of each segment shares the memory of input, so input must
+// not be modified while it is in use.
+func EachBytes(input []byte, fn func(seg Segment) bool, options ...ParseOption) error {
+	return copyError(Each(bytesToString(input), fn, options...))
+}
+
+// CleanseBytes is like Cleanse, but appends the text of input to dst
+// and returns the extended buffer. dst may be input[:0] to cleanse
+// input in place. On error, dst is returned with its original length,
+// but the text before the error has already been written to its
+// spare capacity. When cleansing in place, this means that input has
+// been partly overwritten, so it should be copied first if it is
+// needed after an error.
+func CleanseBytes(dst, input []byte, options ...ParseOption) ([]byte, error) {
+	result, err := appendCleanse(dst, bytesToString(input), options)
+	if err != nil {
+		return dst, copyError(err)
+	}
+	return result, nil
+}
+
+// LengthBytes is like Length, but counts the characters in a byte slice.
+func LengthBytes(input []byte, options ...ParseOption) (int, error) {
+	length, err := Length(bytesToString(input), options...)
+	return length, copyError(err)
+}
+
+// TruncateBytes is like Truncate, but appends the truncated text to
+// dst and returns the extended buffer.
+func TruncateBytes(dst, input []byte, maxChars int, options ...ParseOption) ([]byte, error) {
+	truncated, err := truncate(bytesToString(input), maxChars, options)
+	if err != nil {
+		return dst, copyError(err)
+	}
+	return append(dst, String(truncated)...), nil
+}
+
+// HasEscapeCodesBytes is like HasEscapeCodes, but tests a byte slice.
+func HasEscapeCodesBytes(input []byte) bool {
+	return bytes.IndexAny(input, "\033[") != -1
+}
+
+// ValidateBytes is like Validate, but checks a byte slice without
+// copying it. The Sequence of each Diagnostic shares the memory of
+// input, so input must not be modified while it is in use.
+func ValidateBytes(input []byte) []*Diagnostic {
+	return Validate(bytesToString(input))
+}
+
+// TokenizeBytes is like Tokenize, but splits a byte slice without
+// copying it. The Raw bytes of each Token share the memory of input,
+// so input must not be modified while they are in use. A returned
+// error does not share the memory of input.
+func TokenizeBytes(input []byte) ([]*Token, error) {
+	tokens, err := Tokenize(bytesToString(input))
+	return tokens, copyError(err)
+}
+
+// CollapseBytes is like Collapse, but appends the collapsed text
+// to dst and returns the extended buffer.
+func CollapseBytes(dst, input []byte, options ...ParseOption) ([]byte, error) {
+	collapsed, err := Collapse(bytesToString(input), options...)
+	if err != nil {
+		return dst, copyError(err)
+	}
+	return append(dst, collapsed...), nil
+}
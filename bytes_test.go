@@ -0,0 +1,202 @@
+package ansi
+
+import (
+	"testing"
+
+	is "github.com/matryer/is"
+)
+
+var bytesInputs = []string{
+	"",
+	"Hello World",
+	"\033[1;31mHello\033[0m \033[4;38;5;208mWorld\033[0m",
+	"👩🏽‍🔧\033[38;2;255;128;0m😎 cool\033[0m",
+	"\033]8;;http://a\033\\link\033]8;;\033\\",
+	"Progress 10%\rProgress 100%",
+	"Bad \033[38;5;300mcolour",
+	"Unterminated \033[31",
+}
+
+func TestParseBytes(t *testing.T) {
+	is2 := is.New(t)
+	for _, input := range bytesInputs {
+		want, wantErr := Parse(input)
+		got, err := ParseBytes([]byte(input))
+		is2.Equal(err, wantErr)
+		is2.Equal(len(got), len(want))
+		for index, w := range want {
+			is2.Equal(*got[index], *w)
+		}
+	}
+}
+
+func TestParseBytesSharesMemory(t *testing.T) {
+	is2 := is.New(t)
+	input := []byte("\033[31mRed\033[0m")
+	got, err := ParseBytes(input)
+	is2.NoErr(err)
+	is2.Equal(got[0].Label, "Red")
+	input[5] = 'B'
+	is2.Equal(got[0].Label, "Bed")
+}
+
+func TestEachBytes(t *testing.T) {
+	is2 := is.New(t)
+	var labels []string
+	err := EachBytes([]byte("\033[31mA\033[32mB"), func(seg Segment) bool {
+		labels = append(labels, seg.Label)
+		return true
+	})
+	is2.NoErr(err)
+	is2.Equal(labels, []string{"A", "B"})
+}
+
+func TestCleanseBytes(t *testing.T) {
+	is2 := is.New(t)
+	for _, input := range bytesInputs {
+		want, wantErr := Cleanse(input)
+		got, err := CleanseBytes([]byte("prefix:"), []byte(input))
+		is2.Equal(err != nil, wantErr != nil)
+		if wantErr != nil {
+			is2.Equal(string(got), "prefix:")
+			continue
+		}
+		is2.Equal(string(got), "prefix:"+want)
+	}
+}
+
+func TestCleanseBytesInPlace(t *testing.T) {
+	is2 := is.New(t)
+	input := []byte("\033[1;31mHello\033[0m \033[4mWorld\033[0m")
+	got, err := CleanseBytes(input[:0], input)
+	is2.NoErr(err)
+	is2.Equal(string(got), "Hello World")
+
+	// On error, the text before the error has overwritten input
+	input = []byte("\033[1mHello\033[38;5;300m World")
+	got, err = CleanseBytes(input[:0], input)
+	is2.True(err != nil)
+	is2.Equal(len(got), 0)
+	is2.Equal(string(input[:5]), "Hello")
+}
+
+func TestLengthBytes(t *testing.T) {
+	is2 := is.New(t)
+	for _, input := range bytesInputs {
+		want, wantErr := Length(input)
+		got, err := LengthBytes([]byte(input))
+		is2.Equal(err, wantErr)
+		is2.Equal(got, want)
+	}
+}
+
+func TestTruncateBytes(t *testing.T) {
+	is2 := is.New(t)
+	for _, input := range bytesInputs {
+		want, wantErr := Truncate(input, 3)
+		got, err := TruncateBytes([]byte("prefix:"), []byte(input), 3)
+		is2.Equal(err, wantErr)
+		if wantErr != nil {
+			is2.Equal(string(got), "prefix:")
+			continue
+		}
+		is2.Equal(string(got), "prefix:"+want)
+	}
+}
+
+func TestCollapseBytes(t *testing.T) {
+	is2 := is.New(t)
+	for _, input := range bytesInputs {
+		want, wantErr := Collapse(input)
+		got, err := CollapseBytes(nil, []byte(input))
+		is2.Equal(err, wantErr)
+		is2.Equal(string(got), want)
+	}
+}
+
+func TestBytesHelpers(t *testing.T) {
+	is2 := is.New(t)
+	for _, input := range bytesInputs {
+		is2.Equal(HasEscapeCodesBytes([]byte(input)), HasEscapeCodes(input))
+		is2.Equal(ValidateBytes([]byte(input)), Validate(input))
+		want, wantErr := Tokenize(input)
+		got, err := TokenizeBytes([]byte(input))
+		is2.Equal(err, wantErr)
+		is2.Equal(got, want)
+	}
+}
+
+func TestBytesErrorsCopyInput(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name string
+		call func(input []byte) error
+	}{
+		{"ParseBytes", func(input []byte) error {
+			_, err := ParseBytes(input)
+			return err
+		}},
+		{"EachBytes", func(input []byte) error {
+			return EachBytes(input, func(seg Segment) bool { return true })
+		}},
+		{"CleanseBytes", func(input []byte) error {
+			_, err := CleanseBytes(nil, input)
+			return err
+		}},
+		{"LengthBytes", func(input []byte) error {
+			_, err := LengthBytes(input)
+			return err
+		}},
+		{"TruncateBytes", func(input []byte) error {
+			_, err := TruncateBytes(nil, input, 10)
+			return err
+		}},
+		{"TokenizeBytes", func(input []byte) error {
+			_, err := TokenizeBytes(input)
+			return err
+		}},
+		{"CollapseBytes", func(input []byte) error {
+			_, err := CollapseBytes(nil, input)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Tokenize does not check the parameters, so stops at
+			// the unterminated sequence
+			input := []byte("Bad \033[38;5;300mcolour \033[31")
+			err := tt.call(input)
+			_, ok := err.(*ParseError)
+			is2.True(ok)
+			want := err.Error()
+			for index := range input {
+				input[index] = 'x'
+			}
+			is2.Equal(err.Error(), want)
+		})
+	}
+}
+
+func BenchmarkCleanse(b *testing.B) {
+	for _, bm := range benchmarkInputs {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = Cleanse(bm.input)
+			}
+		})
+	}
+}
+
+func BenchmarkCleanseBytes(b *testing.B) {
+	for _, bm := range benchmarkInputs {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			input := []byte(bm.input)
+			buf := make([]byte, 0, len(input))
+			for i := 0; i < b.N; i++ {
+				buf, _ = CleanseBytes(buf[:0], input)
+			}
+		})
+	}
+}
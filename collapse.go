@@ -0,0 +1,147 @@
+package ansi
+
+import (
+	"strconv"
+
+	"github.com/rivo/uniseg"
+)
+
+// collapseCell is a single user-perceived character on a collapsed line
+type collapseCell struct {
+	char  string
+	style *StyledText
+}
+
+// Collapse applies carriage returns, backspaces and erase in line
+// sequences to the input, in the way a terminal would, and returns
+// the text that remains on each line with its styles.
+// This turns output from tools that redraw progress lines into the
+// text the user saw. Each grapheme cluster takes up a single column.
+// Other control characters, apart from tabs, are removed.
+func Collapse(input string, options ...ParseOption) (string, error) {
+	config := newParseConfig(options)
+	tokens, err := collapseTokens(input, &config)
+	if err != nil {
+		return "", err
+	}
+	var pen StyledText
+	var penStyle *StyledText
+	var result []*StyledText
+	var line []collapseCell
+	column := 0
+	put := func(char string) {
+		cell := collapseCell{char: char, style: penStyle}
+		if column < len(line) {
+			line[column] = cell
+		} else {
+			line = append(line, cell)
+		}
+		column++
+	}
+	for _, token := range tokens {
+		switch token.Type {
+		case TextToken:
+			state := -1
+			var cluster string
+			text := token.Raw
+			for len(text) > 0 {
+				cluster, text, _, state = uniseg.FirstGraphemeClusterInString(text, state)
+				put(cluster)
+			}
+		case ControlToken:
+			switch token.Raw[0] {
+			case '\r':
+				column = 0
+			case '\b':
+				if column > 0 {
+					column--
+				}
+			case '\t':
+				put("\t")
+			case '\n':
+				line = append(line, collapseCell{char: "\n"})
+				result = appendCells(result, line)
+				line = line[:0]
+				column = 0
+			}
+		case SGRToken, OSCToken:
+			previous := pen
+			if _, err := pen.applySequence(token.Raw, &config); err != nil {
+				err = offsetError(err, token.Offset)
+				if !config.recoverFrom(err) {
+					return "", err
+				}
+				pen = previous
+			}
+			penStyle = nil
+			if pen != (StyledText{}) {
+				style := pen
+				penStyle = &style
+			}
+		case CSIToken:
+			if token.Final != 'K' || token.Intermediates != "" {
+				continue
+			}
+			mode, _ := strconv.Atoi(token.Params)
+			switch mode {
+			case 0:
+				if column < len(line) {
+					line = line[:column]
+				}
+			case 1:
+				for x := 0; x <= column && x < len(line); x++ {
+					line[x] = collapseCell{char: " "}
+				}
+			case 2:
+				for x := range line {
+					line[x] = collapseCell{char: " "}
+				}
+			}
+		}
+	}
+	result = appendCells(result, line)
+	return String(result), nil
+}
+
+// collapseTokens splits input into tokens. In recovery mode,
+// malformed escape sequences are dropped.
+func collapseTokens(input string, config *parseConfig) ([]*Token, error) {
+	var result []*Token
+	offset := 0
+	for {
+		tokens, err := tokenize(input[offset:])
+		for _, token := range tokens {
+			token.Offset += offset
+		}
+		result = append(result, tokens...)
+		if err == nil {
+			return result, nil
+		}
+		err = offsetError(err, offset)
+		if !config.recoverFrom(err) {
+			return nil, err
+		}
+		// Drop the malformed sequence
+		parseErr := err.(*ParseError)
+		offset = parseErr.Offset + len(parseErr.Sequence)
+	}
+}
+
+// appendCells appends the cells to result, merging runs of cells
+// with the same style into a single StyledText
+func appendCells(result []*StyledText, cells []collapseCell) []*StyledText {
+	var current *StyledText
+	var style *StyledText
+	for _, cell := range cells {
+		if current == nil || !sameStyle(cell.style, style) {
+			style = cell.style
+			current = &StyledText{}
+			if style != nil {
+				*current = *style
+			}
+			result = append(result, current)
+		}
+		current.Label += cell.char
+	}
+	return result
+}
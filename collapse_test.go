@@ -0,0 +1,71 @@
+package ansi
+
+import (
+	"testing"
+
+	is "github.com/matryer/is"
+)
+
+func TestCollapse(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"Blank", "", "", false},
+		{"No formatting", "Hello World", "Hello World", false},
+		{"Carriage return", "Progress 10%\rProgress 100%", "Progress 100%", false},
+		{"Shorter overwrite", "Downloading\rDone", "Doneloading", false},
+		{"Backspace", "Hellp\bo", "Hello", false},
+		{"Backspace at start", "\b\bHi", "Hi", false},
+		{"Multiple lines", "one\r1\ntwo\r2\r\nthree", "1ne\n2wo\nthree", false},
+		{"Erase to end of line", "Downloading\r\033[KDone", "Done", false},
+		{"Erase to start of line", "Downloading\b\b\b\b\033[1Kx", "       xing", false},
+		{"Erase line", "Downloading\033[2K\rDone", "Done       ", false},
+		{"Control characters removed", "a\ab\tc", "ab\tc", false},
+		{"Cursor movement ignored", "ab\033[2Dcd", "abcd", false},
+		{"Grapheme clusters", "👩🏽‍🔧😎\b!", "👩🏽‍🔧!", false},
+		{"Styles kept", "\033[31mRed\033[0m\r\033[1mB", "\033[0;1mB\033[0m\033[0;31med\033[0m", false},
+		{"Styles merged", "\033[31mabc\rxy\033[0m", "\033[0;31mxyc\033[0m", false},
+		{"Newline unstyled", "\033[31mone\ntwo\033[0m", "\033[0;31mone\033[0m\n\033[0;31mtwo\033[0m", false},
+		{"Hyperlinks kept", "\033]8;;http://a\033\\link\033]8;;\033\\\rL", "L\033]8;;http://a\033\\ink\033]8;;\033\\", false},
+		{"Missing terminator", "Hello\033[31", "", true},
+		{"Bad code", "\033[38;5;300mHello", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Collapse(tt.input)
+			is2.Equal(err != nil, tt.wantErr)
+			is2.Equal(got, tt.want)
+		})
+	}
+}
+
+func TestCollapseWithRecovery(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Missing terminator", "abc\r\033[31", "abc"},
+		{"Carriage return in bad sequence", "abc\033[31\rX", "abcX"},
+		{"Unterminated hyperlink", "Hello\r\033]8;;http://a\rJ", "Hello"},
+		{"Bad code", "\033[38;5;300mHello\rJ", "Jello"},
+		{"Text after bad sequence", "\033[31mRed\033[\x01b", "\033[0;31mRedb\033[0m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs []*ParseError
+			handler := func(err *ParseError) {
+				errs = append(errs, err)
+			}
+			got, err := Collapse(tt.input, WithRecoveryHandler(handler))
+			is2.NoErr(err)
+			is2.Equal(got, tt.want)
+			is2.Equal(len(errs), 1)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package ansi
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// decoderChunkSize is the smallest number of bytes requested from
+// the underlying reader on each read
+const decoderChunkSize = 4096
+
+// Decoder reads and parses an ANSI encoded stream, yielding
+// StyledText segments as soon as they are available.
+// The style is carried across reads, so a sequence set in one
+// chunk applies to text in the following chunks.
+type Decoder struct {
+	r      io.Reader
+	config parseConfig
+
+	// buf holds the input that has been read, of which the bytes
+	// from start onwards have not been decoded yet. It is reused
+	// between reads, so nothing returned may share its memory.
+	buf   []byte
+	start int
+	// eof is set once the reader has no more input, either at the end
+	// of the stream or after readErr, which is returned once the
+	// buffered input has been decoded
+	eof     bool
+	readErr error
+	err     error
+
+	current       StyledText
+	offset        int
+	escapeCodeLen int
+
+	// pending holds the decoded overstrike segments that have
+	// not been returned yet
+	pending []*StyledText
+}
+
+// NewDecoder returns a new Decoder that reads from r.
+// The options are applied in the same way as they are for Parse.
+// With WithOverstrike, the last character of each read is held back
+// until the next read, in case it is struck over.
+func NewDecoder(r io.Reader, options ...ParseOption) *Decoder {
+	return &Decoder{
+		r:      r,
+		config: newParseConfig(options),
+	}
+}
+
+// Decode returns the next StyledText segment in the stream.
+// A run of text with the same style may be returned as several
+// segments if it spans multiple reads. At the end of the stream,
+// Decode returns io.EOF.
+func (d *Decoder) Decode() (*StyledText, error) {
+	for {
+		if len(d.pending) > 0 {
+			result := d.pending[0]
+			d.pending = d.pending[1:]
+			return result, nil
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		unread := bytesToString(d.buf[d.start:])
+		esc := indexSequence(unread)
+		if esc != 0 {
+			text := unread
+			if esc > 0 {
+				text = unread[:esc]
+			} else if !d.eof {
+				text = d.completeText(unread)
+			}
+			if len(text) > 0 {
+				d.start += len(text)
+				return d.emit(text), nil
+			}
+			if d.eof {
+				d.err = io.EOF
+				if d.readErr != nil {
+					d.err = d.readErr
+				}
+				continue
+			}
+			d.fill()
+			continue
+		}
+
+		// Read in the sequence
+		previous := d.current
+		length, err := d.current.applySequence(unread, &d.config)
+		if errors.Is(err, ErrMissingTerminator) {
+			if !d.eof {
+				d.fill()
+				continue
+			}
+			if d.readErr != nil {
+				// The rest of the sequence could not be read
+				d.err = d.readErr
+				continue
+			}
+		}
+		if err != nil {
+			err = copyError(offsetError(err, d.offset+d.escapeCodeLen))
+			if !d.config.recoverFrom(err) {
+				d.err = err
+				continue
+			}
+			// Drop the malformed sequence
+			d.current = previous
+		}
+		if link := d.current.Hyperlink; link != nil && link != previous.Hyperlink {
+			link.URL = cloneString(link.URL)
+			link.ID = cloneString(link.ID)
+		}
+		d.start += length
+		d.escapeCodeLen += length
+	}
+}
+
+// emit returns a copy of the current style with the given label
+// and advances the offset. Overstrike sequences are decoded if the
+// option is set, and any segments after the first are kept for the
+// following calls to Decode.
+func (d *Decoder) emit(label string) *StyledText {
+	result := &StyledText{
+		Label:      cloneString(label),
+		FgCol:      d.current.FgCol,
+		BgCol:      d.current.BgCol,
+		Style:      d.current.Style,
+		FgMode:     d.current.FgMode,
+		BgMode:     d.current.BgMode,
+		ColourMode: d.current.ColourMode,
+		Underline:  d.current.Underline,
+		Font:       d.current.Font,
+		UlCol:      d.current.UlCol,
+		Hyperlink:  d.current.Hyperlink,
+		Offset:     d.offset,
+		Len:        len(label) + d.escapeCodeLen,
+	}
+	d.offset += result.Len
+	d.escapeCodeLen = 0
+	if !d.config.overstrike || strings.IndexByte(label, '\b') == -1 {
+		return result
+	}
+	decoded := overstrikeText(result)
+	d.pending = append(d.pending, decoded[1:]...)
+	return decoded[0]
+}
+
+// completeText returns the prefix of text that can safely be emitted
+// without splitting an escape code, a UTF-8 encoded rune or an
+// overstrike sequence that may continue in the next read
+func (d *Decoder) completeText(text string) string {
+	if len(text) > 0 && text[len(text)-1] == '\033' {
+		text = text[:len(text)-1]
+	}
+	text = trimPartialRune(text)
+	if d.config.overstrike {
+		text = trimPartialOverstrike(text)
+	}
+	return text
+}
+
+// trimPartialOverstrike removes the last character from the end of
+// text, as the next read may strike over it, along with any
+// overstrike sequence that it ends
+func trimPartialOverstrike(text string) string {
+	end := len(text)
+	for end > 0 {
+		char, size := utf8.DecodeLastRuneInString(text[:end])
+		end -= size
+		if char != '\b' && (end == 0 || text[end-1] != '\b') {
+			break
+		}
+	}
+	return text[:end]
+}
+
+// trimPartialRune removes an incomplete UTF-8 encoded rune
+// from the end of text
+func trimPartialRune(text string) string {
+	for i := 1; i < utf8.UTFMax && i <= len(text); i++ {
+		b := text[len(text)-i]
+		if utf8.RuneStart(b) {
+			if !utf8.FullRuneInString(text[len(text)-i:]) {
+				return text[:len(text)-i]
+			}
+			break
+		}
+	}
+	return text
+}
+
+// fill reads the next chunk from the underlying reader into the
+// buffer, first moving the bytes that have not been decoded to the
+// start of the buffer if any have been
+func (d *Decoder) fill() {
+	if d.start > 0 {
+		d.buf = d.buf[:copy(d.buf, d.buf[d.start:])]
+		d.start = 0
+	}
+	if cap(d.buf)-len(d.buf) < decoderChunkSize {
+		grown := make([]byte, len(d.buf), 2*cap(d.buf)+decoderChunkSize)
+		copy(grown, d.buf)
+		d.buf = grown
+	}
+	n, err := d.r.Read(d.buf[len(d.buf):cap(d.buf)])
+	d.buf = d.buf[:len(d.buf)+n]
+	if err != nil {
+		d.eof = true
+		if err != io.EOF {
+			d.readErr = err
+		}
+	}
+}
+
+// cloneString returns a copy of s that does not share its memory
+func cloneString(s string) string {
+	if s == "" {
+		return ""
+	}
+	var result strings.Builder
+	result.Grow(len(s))
+	result.WriteString(s)
+	return result.String()
+}
@@ -0,0 +1,210 @@
+package ansi
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf8"
+
+	is "github.com/matryer/is"
+)
+
+func decodeAll(d *Decoder) ([]*StyledText, error) {
+	var result []*StyledText
+	for {
+		text, err := d.Decode()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+		result = append(result, text)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"No formatting", "Hello World"},
+		{"Black & Red", "\u001B[0;30mHello World\u001B[0m\u001B[0;31mHello World\u001B[0m"},
+		{"Text then Black & Red", "This is great!\u001B[0;30mHello World\u001B[0m\u001B[0;31mHello World\u001B[0m"},
+		{"Black,space,Red", "\u001B[0;30mHello World\u001B[0m \u001B[0;31mHello World\u001B[0m"},
+		{"Grey93 Bold & DarkViolet Italic", "\u001B[0;1;38;5;255mGrey93\u001B[0m\u001B[0;3;38;5;128mDarkViolet\u001B[0m"},
+		{"Red, text, Green", "\u001B[38;2;255;0;0mRed\u001B[0mI am plain text\u001B[38;2;0;255;0mGreen\u001B[0m"},
+		{"Control sequences", "\033[2K\033[31mdone\033[10;20H \033[0m"},
+		{"Hyperlink", "See \033]8;id=1;https://example.com\033\\\033[1mhere\033[0m\033]8;;\a now"},
+		{"Emoji", "\u001B[2;32m👩🏽‍🔧\u001B[0m\u001B[0;3;33m👩🏽‍🔧\u001B[0m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := Parse(tt.input)
+			is2.NoErr(err)
+			got, err := decodeAll(NewDecoder(strings.NewReader(tt.input)))
+			is2.NoErr(err)
+			is2.Equal(len(got), len(want))
+			for index, w := range want {
+				is2.Equal(got[index].Label, w.Label)
+				is2.Equal(got[index].FgCol, w.FgCol)
+				is2.Equal(got[index].BgCol, w.BgCol)
+				is2.Equal(got[index].Style, w.Style)
+				is2.Equal(got[index].Hyperlink, w.Hyperlink)
+				is2.Equal(got[index].Offset, w.Offset)
+				is2.Equal(got[index].Len, w.Len)
+			}
+		})
+	}
+}
+
+func TestDecoderSplitReads(t *testing.T) {
+	is2 := is.New(t)
+	input := "plain \033]8;;https://example.com\033\\link\033]8;;\033\\ \u001B[1;31mI am Red\u001B[0m & \u001B[38;5;128m👩🏽‍🔧 violet\u001B[0m"
+	got, err := decodeAll(NewDecoder(iotest.OneByteReader(strings.NewReader(input))))
+	is2.NoErr(err)
+
+	want, err := Parse(input)
+	is2.NoErr(err)
+
+	// Merge the segments split by the reads
+	var merged []*StyledText
+	length := 0
+	for _, text := range got {
+		is2.True(utf8.ValidString(text.Label))
+		is2.Equal(text.Offset, length)
+		length += text.Len
+		if len(merged) > 0 && text.Len == len(text.Label) {
+			merged[len(merged)-1].Label += text.Label
+			continue
+		}
+		merged = append(merged, text)
+	}
+	is2.Equal(len(merged), len(want))
+	last := want[len(want)-1]
+	is2.Equal(length, last.Offset+last.Len)
+	for index, w := range want {
+		is2.Equal(merged[index].Label, w.Label)
+		is2.Equal(merged[index].FgCol, w.FgCol)
+		is2.Equal(merged[index].Style, w.Style)
+		is2.Equal(merged[index].Hyperlink, w.Hyperlink)
+	}
+}
+
+func TestDecoderOverstrike(t *testing.T) {
+	is2 := is.New(t)
+	input := "B\bBold and _\bu_\bn_\bd_\be_\br \033[31mR\bRed\033[0m _\bB\bB"
+	want, err := Parse(input, WithOverstrike())
+	is2.NoErr(err)
+
+	readers := map[string]func() io.Reader{
+		"Whole":     func() io.Reader { return strings.NewReader(input) },
+		"Split":     func() io.Reader { return iotest.OneByteReader(strings.NewReader(input)) },
+		"Half read": func() io.Reader { return iotest.HalfReader(strings.NewReader(input)) },
+	}
+	for name, reader := range readers {
+		t.Run(name, func(t *testing.T) {
+			got, err := decodeAll(NewDecoder(reader(), WithOverstrike()))
+			is2.NoErr(err)
+			is2.True(sameRuns(got, want))
+			length := 0
+			for _, text := range got {
+				is2.Equal(text.Offset, length)
+				length += text.Len
+			}
+			is2.Equal(length, len(input))
+		})
+	}
+}
+
+func TestDecoderLongSequence(t *testing.T) {
+	is2 := is.New(t)
+	url := "https://example.com/" + strings.Repeat("a", 100000)
+	input := "before \033]8;;" + url + "\033\\link\033]8;;\033\\ after"
+	got, err := decodeAll(NewDecoder(iotest.HalfReader(strings.NewReader(input))))
+	is2.NoErr(err)
+
+	// The segments do not share the reused read buffer
+	var text, linked strings.Builder
+	for _, segment := range got {
+		text.WriteString(segment.Label)
+		if segment.Hyperlink != nil {
+			is2.Equal(segment.Hyperlink.URL, url)
+			linked.WriteString(segment.Label)
+		}
+	}
+	is2.Equal(text.String(), "before link after")
+	is2.Equal(linked.String(), "link")
+}
+
+// dataErrReader returns its data along with err on each read
+type dataErrReader struct {
+	data string
+	err  error
+}
+
+func (r *dataErrReader) Read(p []byte) (int, error) {
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, r.err
+}
+
+func TestDecoderReadError(t *testing.T) {
+	is2 := is.New(t)
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"Text", "hello", []string{"hello"}},
+		{"Styled text", "hello \033[1mworld", []string{"hello ", "world"}},
+		{"Unterminated sequence", "hello\033[1", []string{"hello"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The bytes returned with the error are decoded first
+			d := NewDecoder(&dataErrReader{data: tt.input, err: errBoom})
+			got, err := decodeAll(d)
+			is2.Equal(err, errBoom)
+			is2.Equal(len(got), len(tt.want))
+			for index, want := range tt.want {
+				is2.Equal(got[index].Label, want)
+			}
+			_, err = d.Decode()
+			is2.Equal(err, errBoom)
+		})
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name    string
+		input   string
+		options []ParseOption
+		wantErr error
+	}{
+		{"Missing terminator", "Hello\033[44;32;12", nil, ErrMissingTerminator},
+		{"Invalid code", "\u001b[0;99mHello World\033[0m", nil, ErrInvalid},
+		{"Invalid code ignored", "\u001b[0;99mHello World\033[0m", []ParseOption{WithIgnoreInvalidCodes()}, nil},
+		{"Bad 256 colour", "\u001B[38;5;256mGrey93\u001B[0m", nil, ErrInvalid256ColSequence},
+		{"Bad 256 colour recovered", "\u001B[38;5;256mGrey93\u001B[0m", []ParseOption{WithRecovery()}, nil},
+		{"Missing terminator recovered", "Hello\033[44;32;12", []ParseOption{WithRecovery()}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecoder(iotest.HalfReader(strings.NewReader(tt.input)), tt.options...)
+			_, err := decodeAll(d)
+			is2.True(errors.Is(err, tt.wantErr))
+			if tt.wantErr != nil {
+				// Errors are sticky
+				_, err2 := d.Decode()
+				is2.Equal(err2, err)
+			}
+		})
+	}
+}
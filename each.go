@@ -0,0 +1,95 @@
+package ansi
+
+import "strings"
+
+// Segment is a run of text with a single style, as passed to the
+// callback of Each. Segments are passed by value, so that no memory
+// is allocated for each one.
+type Segment struct {
+	StyledText
+}
+
+// Each calls fn with each segment of styled text in input, in order,
+// and stops if fn returns false. Unlike Parse, Each does not allocate
+// memory for each segment, which suits hot paths such as parsing
+// every line of a log. The only allocations are for the colours of
+// true colour sequences and for hyperlinks. The Label of each segment
+// is a substring of input, and the Offset and Len are set as they are
+// by Parse.
+// The options are applied in the same way as they are for Parse.
+// If parsing is unsuccessful, a *ParseError is returned. Segments
+// before the error will already have been passed to fn.
+func Each(input string, fn func(seg Segment) bool, options ...ParseOption) error {
+	var state StyledText
+	config := newParseConfig(options)
+	return each(input, &state, &config, func(text StyledText) bool {
+		return fn(Segment{text})
+	})
+}
+
+// each calls fn with each segment of input, starting with the style
+// in state, and stops if fn returns false. If the whole of the input
+// is parsed successfully, state is updated to the style at the end.
+func each(input string, state *StyledText, config *parseConfig, fn func(text StyledText) bool) error {
+	current := *state
+	current.Label = ""
+	current.Offset = 0
+	current.Len = 0
+	offset := 0
+	escapeCodeLen := 0
+	index := 0
+	for {
+		// Read all chars to next escape code
+		esc := indexSequence(input[index:])
+		end := index + esc
+		if esc == -1 {
+			end = len(input)
+		}
+		if end > index {
+			current.Label = input[index:end]
+			current.Offset = offset
+			current.Len = end - index + escapeCodeLen
+			if !emit(current, config, fn) {
+				return nil
+			}
+			offset += current.Len
+			escapeCodeLen = 0
+			current.Label = ""
+			current.Offset = 0
+			current.Len = 0
+		}
+		if esc == -1 {
+			*state = current
+			return nil
+		}
+		index = end
+
+		// Read in the sequence
+		previous := current
+		length, err := current.applySequence(input[index:], config)
+		if err != nil {
+			err = offsetError(err, index)
+			if !config.recoverFrom(err) {
+				return err
+			}
+			// Drop the malformed sequence
+			current = previous
+		}
+		index += length
+		escapeCodeLen += length
+	}
+}
+
+// emit passes text to fn, decoding any overstrike sequences if
+// the option is set. It returns false if fn does.
+func emit(text StyledText, config *parseConfig, fn func(text StyledText) bool) bool {
+	if !config.overstrike || strings.IndexByte(text.Label, '\b') == -1 {
+		return fn(text)
+	}
+	for _, decoded := range overstrikeText(&text) {
+		if !fn(*decoded) {
+			return false
+		}
+	}
+	return true
+}
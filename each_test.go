@@ -0,0 +1,138 @@
+package ansi
+
+import (
+	"testing"
+
+	is "github.com/matryer/is"
+)
+
+func TestEach(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name    string
+		input   string
+		options []ParseOption
+	}{
+		{"Blank", "", nil},
+		{"No formatting", "Hello World", nil},
+		{"Styles", "\033[1;31mHello\033[0m \033[4;38;5;208mWorld\033[0m", nil},
+		{"True colour", "\033[38;2;255;128;0mOrange\033[48;2;0;0;128m on navy", nil},
+		{"Hyperlink", "\033]8;id=1;http://a\033\\link\033]8;;\033\\ text", nil},
+		{"Overstrike", "N\bNA\bAM\bME\bE _\bf_\bi_\bl_\be", []ParseOption{WithOverstrike()}},
+		{"Recovery", "\033[38;5;999mA\033[1mB", []ParseOption{WithRecovery()}},
+		{"Options", "\033[31;39mA", []ParseOption{WithDefaultForegroundColor("32")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := Parse(tt.input, tt.options...)
+			is2.NoErr(err)
+			if tt.input == "" {
+				want = nil
+			}
+			var got []StyledText
+			err = Each(tt.input, func(seg Segment) bool {
+				got = append(got, seg.StyledText)
+				return true
+			}, tt.options...)
+			is2.NoErr(err)
+			is2.Equal(len(got), len(want))
+			for index, w := range want {
+				is2.Equal(got[index], *w)
+			}
+		})
+	}
+}
+
+func TestEachStop(t *testing.T) {
+	is2 := is.New(t)
+	var labels []string
+	err := Each("\033[31mA\033[32mB\033[33mC", func(seg Segment) bool {
+		labels = append(labels, seg.Label)
+		return seg.Label != "B"
+	})
+	is2.NoErr(err)
+	is2.Equal(labels, []string{"A", "B"})
+
+	// Stopping part way through decoded overstrike text
+	labels = nil
+	err = Each("A\bAB_\bC", func(seg Segment) bool {
+		labels = append(labels, seg.Label)
+		return false
+	}, WithOverstrike())
+	is2.NoErr(err)
+	is2.Equal(labels, []string{"A"})
+}
+
+func TestEachError(t *testing.T) {
+	is2 := is.New(t)
+	var labels []string
+	err := Each("A\033[38;5;300mB", func(seg Segment) bool {
+		labels = append(labels, seg.Label)
+		return true
+	})
+	is2.True(err != nil)
+	is2.Equal(labels, []string{"A"})
+}
+
+func TestEachAllocations(t *testing.T) {
+	is2 := is.New(t)
+	input := "\033[1;32m2021-06-01 12:00:00\033[0m \033[34mINFO\033[0m server \033[38;5;208mstarted\033[0m"
+	count := 0
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = Each(input, func(seg Segment) bool {
+			if seg.Bold() {
+				count++
+			}
+			return true
+		})
+	})
+	is2.Equal(allocs, float64(0))
+}
+
+var benchmarkInputs = []struct {
+	name  string
+	input string
+}{
+	{"Plain", "2021-06-01 12:00:00 INFO server started on port 8080"},
+	{"16 colours", "\033[1;32m2021-06-01 12:00:00\033[0m \033[34mINFO\033[0m server started on \033[4mport 8080\033[0m"},
+	{"256 colours", "\033[38;5;208m2021-06-01\033[0m \033[48;5;18;38;5;15mINFO\033[0m server started\033[0m"},
+	{"True colour", "\033[38;2;255;128;0m2021-06-01\033[0m \033[48;2;0;0;128mINFO\033[0m server started\033[0m"},
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, bm := range benchmarkInputs {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = Parse(bm.input)
+			}
+		})
+	}
+}
+
+func BenchmarkEach(b *testing.B) {
+	for _, bm := range benchmarkInputs {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			length := 0
+			for i := 0; i < b.N; i++ {
+				_ = Each(bm.input, func(seg Segment) bool {
+					length += len(seg.Label)
+					return true
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkParser(b *testing.B) {
+	for _, bm := range benchmarkInputs {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			p := NewParser()
+			for i := 0; i < b.N; i++ {
+				_, _ = p.Parse(bm.input)
+			}
+		})
+	}
+}
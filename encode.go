@@ -0,0 +1,406 @@
+package ansi
+
+import (
+	"strconv"
+	"strings"
+)
+
+// sgrFeature is one of the attributes of a style that is selected
+// by SGR parameters
+type sgrFeature struct {
+	// params appends the parameters that select the attribute of s
+	// to dst. Nothing is appended if the attribute is not set.
+	params func(s *StyledText, colon bool, dst []string) []string
+	// off is the parameter that turns the attribute off. All of the
+	// attributes that share a parameter are turned off by it.
+	off string
+	// colour returns the colour selected by the attribute, if it
+	// is a colour
+	colour func(s *StyledText) *Col
+	// need returns the colour map needed by the 16 colour code for
+	// the colour, for colours that have one
+	need func(s *StyledText) colourMapNeed
+}
+
+// flagFeature returns a feature for a style flag
+func flagFeature(style TextStyle, param string, off string) sgrFeature {
+	return sgrFeature{
+		params: func(s *StyledText, colon bool, dst []string) []string {
+			if s.Style&style == style {
+				dst = append(dst, param)
+			}
+			return dst
+		},
+		off: off,
+	}
+}
+
+// sgrFeatures holds the attributes in the order their parameters
+// are written
+var sgrFeatures = [...]sgrFeature{
+	flagFeature(Bold, "1", "22"),
+	flagFeature(Faint, "2", "22"),
+	flagFeature(Italic, "3", "23"),
+	{params: underlineParams, off: "24"},
+	flagFeature(Blinking, "5", "25"),
+	flagFeature(RapidBlinking, "6", "25"),
+	flagFeature(Inversed, "7", "27"),
+	flagFeature(Invisible, "8", "28"),
+	flagFeature(Strikethrough, "9", "29"),
+	{params: fontParams, off: "10"},
+	flagFeature(Fraktur, "20", "23"),
+	flagFeature(Framed, "51", "54"),
+	flagFeature(Encircled, "52", "54"),
+	flagFeature(Overlined, "53", "55"),
+	flagFeature(Superscript, "73", "75"),
+	flagFeature(Subscript, "74", "75"),
+	{
+		params: fgParams,
+		off:    "39",
+		colour: func(s *StyledText) *Col { return s.FgCol },
+		need:   func(s *StyledText) colourMapNeed { return s.colourMapNeed(s.FgCol, s.fgMode()) },
+	},
+	{
+		params: bgParams,
+		off:    "49",
+		colour: func(s *StyledText) *Col { return s.BgCol },
+		need:   func(s *StyledText) colourMapNeed { return s.colourMapNeed(s.BgCol, s.bgMode()) },
+	},
+	{params: ulColParams, off: "59", colour: func(s *StyledText) *Col { return s.UlCol }},
+}
+
+// intensityFeatures are the features for bold and faint, which
+// select the colour map used by the 16 colour codes
+const intensityFeatures = 2
+
+func underlineParams(s *StyledText, colon bool, dst []string) []string {
+	switch s.Underline {
+	case UnderlineNone, UnderlineSingle:
+		if s.Underlined() {
+			dst = append(dst, "4")
+		}
+	case UnderlineDouble:
+		if colon {
+			dst = append(dst, "4:2")
+		} else {
+			dst = append(dst, "21")
+		}
+	default:
+		// Only available as sub-parameters
+		dst = append(dst, "4:"+strconv.Itoa(int(s.Underline)))
+	}
+	return dst
+}
+
+func fontParams(s *StyledText, colon bool, dst []string) []string {
+	if s.Font > 0 && s.Font < 10 {
+		dst = append(dst, strconv.Itoa(10+s.Font))
+	}
+	return dst
+}
+
+func fgParams(s *StyledText, colon bool, dst []string) []string {
+	return s.colourParams(dst, "38", 30, s.FgCol, s.fgMode(), colon)
+}
+
+func bgParams(s *StyledText, colon bool, dst []string) []string {
+	return s.colourParams(dst, "48", 40, s.BgCol, s.bgMode(), colon)
+}
+
+func ulColParams(s *StyledText, colon bool, dst []string) []string {
+	// Underline colours are only available as 256 or true colours
+	return s.colourParams(dst, "58", 0, s.UlCol, TwoFiveSix, colon)
+}
+
+// colourParams appends the parameters that select col to dst. code is
+// the extended colour code, eg. 38, and offset is the 16 colour code of
+// the first standard colour, eg. 30.
+func (s *StyledText) colourParams(dst []string, code string, offset int, col *Col, mode ColourMode, colon bool) []string {
+	if col == nil {
+		return dst
+	}
+	if mode == Default && offset > 0 && col.Id >= 0 && col.Id < 16 {
+		if param, ok := s.colourCode(col.Id, offset); ok {
+			return append(dst, param)
+		}
+	}
+	if mode != TrueColour && (col.Id < 0 || col.Id > 255) {
+		mode = TrueColour
+	} else if mode == Default {
+		mode = TwoFiveSix
+	}
+	return append(dst, extendedColourParams(code, col, mode, colon)...)
+}
+
+// colourCode returns the 16 colour code for the colour with the given
+// id. The 8 bright colours use the bright codes, eg. 91, when s has the
+// Bright style, and the standard codes with the Bold colour map when s
+// is bold. Otherwise there is no 16 colour code for them, and false
+// is returned.
+func (s *StyledText) colourCode(id int, offset int) (string, bool) {
+	if id > 7 {
+		switch {
+		case s.Bright():
+			offset += 60
+		case !s.Bold():
+			return "", false
+		}
+		id -= 8
+	}
+	return strconv.Itoa(id + offset), true
+}
+
+// colourMapNeed is the colour map that must be in use when the
+// parameters for a colour are applied
+type colourMapNeed int
+
+const (
+	// anyColourMap is used for colours that select the same
+	// colour with every map
+	anyColourMap colourMapNeed = iota
+	// regularColourMap is used for the 8 standard colours of bold
+	// text, which are selected before the text is made bold
+	regularColourMap
+)
+
+// colourMapNeed returns the colour map needed to select col. Only the
+// 8 standard colours of bold text need a particular map, as the bright
+// colours of bold text are selected once the text is bold.
+func (s *StyledText) colourMapNeed(col *Col, mode ColourMode) colourMapNeed {
+	if s.Bold() && col != nil && mode == Default && col.Id >= 0 && col.Id < 8 {
+		return regularColourMap
+	}
+	return anyColourMap
+}
+
+// brightCode returns true if col is selected by one of the
+// bright codes, eg. 91, which set the Bright style
+func (s *StyledText) brightCode(col *Col, mode ColourMode) bool {
+	return s.Bright() && col != nil && mode == Default && col.Id > 7 && col.Id < 16
+}
+
+// paramOrder returns the indexes of sgrFeatures in the order their
+// parameters are written for s. The 16 colour codes select a colour
+// from the Bold colour map when the text is already bold, so bold and
+// faint come after a standard colour of bold text, eg. 31;1 for a bold
+// standard red and 1;31 for a bright red.
+func (s *StyledText) paramOrder() []int {
+	var intensity, order, colours []int
+	late := false
+	for i, feature := range sgrFeatures {
+		switch {
+		case i < intensityFeatures:
+			intensity = append(intensity, i)
+		case feature.need != nil && feature.need(s) == regularColourMap:
+			order = append(order, i)
+			late = true
+		case feature.colour != nil:
+			colours = append(colours, i)
+		default:
+			order = append(order, i)
+		}
+	}
+	if late {
+		order = append(order, intensity...)
+	} else {
+		order = append(intensity, order...)
+	}
+	return append(order, colours...)
+}
+
+// styleToParams returns the SGR parameters for the style of s.
+// If colon is true, extended colours use the ITU T.416 colon
+// separated form. Parsing the parameters gives the same style as s.
+func (s *StyledText) styleToParams(colon bool) []string {
+	var params []string
+	bright := s.Bright() && !s.brightCode(s.FgCol, s.fgMode()) && !s.brightCode(s.BgCol, s.bgMode())
+	for _, i := range s.paramOrder() {
+		if bright && sgrFeatures[i].colour != nil {
+			// The Bright style is only set by the bright codes, so
+			// use one that the foreground colour then replaces
+			params = append(params, "90")
+			if s.FgCol == nil {
+				params = append(params, "39")
+			}
+			bright = false
+		}
+		params = sgrFeatures[i].params(s, colon, params)
+	}
+	return params
+}
+
+// minimalEncoder builds an ANSI string that only changes the
+// attributes that differ between one StyledText and the next
+type minimalEncoder struct {
+	colon bool
+	// config is used to check the parameters by parsing them
+	config parseConfig
+	result strings.Builder
+	// state is the style the terminal is left in
+	state StyledText
+}
+
+// write appends text, selecting its style from the current state
+func (e *minimalEncoder) write(text *StyledText) {
+	if text.Label == "" {
+		return
+	}
+	if !sameHyperlink(e.state.Hyperlink, text.Hyperlink) {
+		if text.Hyperlink == nil {
+			e.result.WriteString(e.state.linkEnd())
+		} else {
+			// Opening a link closes any link that is open
+			e.result.WriteString(text.linkStart())
+		}
+	}
+	if params := e.transitionParams(&e.state, text); len(params) > 0 {
+		e.result.WriteString("\033[" + strings.Join(params, ";") + "m")
+	}
+	e.result.WriteString(text.Label)
+	e.state = *text
+	e.state.Label = ""
+}
+
+// close resets the style and closes any open link
+func (e *minimalEncoder) close() {
+	if e.state.Bright() || len(e.state.styleToParams(e.colon)) > 0 {
+		e.result.WriteString("\033[0m")
+	}
+	e.result.WriteString(e.state.linkEnd())
+	e.state = StyledText{}
+}
+
+// transitionParams returns the shortest SGR parameters that change
+// the style from the style of from to the style of to. The result
+// is either the attributes that change, turning off those that are
+// no longer set, or a reset followed by the whole of the new style.
+func (e *minimalEncoder) transitionParams(from, to *StyledText) []string {
+	reset := append([]string{"0"}, to.styleToParams(e.colon)...)
+
+	// The bright flag can only be cleared by a reset
+	if from.Bright() && !to.Bright() {
+		return reset
+	}
+
+	var fromParams, toParams [len(sgrFeatures)][]string
+	for i, feature := range sgrFeatures {
+		fromParams[i] = feature.params(from, e.colon, nil)
+		toParams[i] = feature.params(to, e.colon, nil)
+	}
+	var changed [len(sgrFeatures)]bool
+	var offParams []string
+	for i, feature := range sgrFeatures {
+		if len(toParams[i]) > 0 {
+			if !sameParams(fromParams[i], toParams[i]) {
+				changed[i] = true
+			}
+			if feature.colour != nil && !sameColour(feature.colour(from), feature.colour(to)) {
+				changed[i] = true
+			}
+			continue
+		}
+		if len(fromParams[i]) == 0 || containsParam(offParams, feature.off) {
+			continue
+		}
+		offParams = append(offParams, feature.off)
+		// The parameter also turns off the attributes that share it
+		for j, other := range sgrFeatures {
+			if other.off == feature.off && len(toParams[j]) > 0 {
+				changed[j] = true
+			}
+		}
+	}
+
+	// The 16 colour codes take their colour from the bold and faint
+	// parameters before them, so also try the changes with those.
+	// If neither gives the new style, reset.
+	resetLength := len(strings.Join(reset, ";"))
+	order := to.paramOrder()
+	for _, intensity := range []bool{false, true} {
+		params := append([]string(nil), offParams...)
+		for _, i := range order {
+			if changed[i] || intensity && i < intensityFeatures {
+				params = append(params, toParams[i]...)
+			}
+		}
+		if len(strings.Join(params, ";")) <= resetLength && e.reaches(from, to, params) {
+			return params
+		}
+	}
+	return reset
+}
+
+// reaches returns true if applying params to the style of from
+// gives the style of to
+func (e *minimalEncoder) reaches(from, to *StyledText, params []string) bool {
+	state := *from
+	state.Hyperlink = to.Hyperlink
+	if len(params) > 0 {
+		if err := state.applySGR(strings.Join(params, ";"), &e.config); err != nil {
+			return false
+		}
+	}
+	return equalStyle(&state, to)
+}
+
+// equalStyle returns true if a and b have the same style, comparing
+// colours and hyperlinks by value
+func equalStyle(a, b *StyledText) bool {
+	if !sameColour(a.FgCol, b.FgCol) || !sameColour(a.BgCol, b.BgCol) ||
+		!sameColour(a.UlCol, b.UlCol) || !sameHyperlink(a.Hyperlink, b.Hyperlink) {
+		return false
+	}
+	return a.Style&^Underlined == b.Style&^Underlined &&
+		a.underlineStyle() == b.underlineStyle() &&
+		a.fgMode() == b.fgMode() &&
+		a.bgMode() == b.bgMode() &&
+		a.Font == b.Font
+}
+
+// underlineStyle returns the style of underline, treating the
+// Underlined style without an underline style as a single underline
+func (s *StyledText) underlineStyle() UnderlineStyle {
+	if s.Underline == UnderlineNone && s.Underlined() {
+		return UnderlineSingle
+	}
+	return s.Underline
+}
+
+// sameParams returns true if a and b hold the same parameters
+func sameParams(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// containsParam returns true if params includes param
+func containsParam(params []string, param string) bool {
+	for _, p := range params {
+		if p == param {
+			return true
+		}
+	}
+	return false
+}
+
+// sameColour returns true if a and b are the same colour
+func sameColour(a, b *Col) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// sameHyperlink returns true if a and b are the same link
+func sameHyperlink(a, b *Hyperlink) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
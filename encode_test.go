@@ -0,0 +1,236 @@
+package ansi
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestStringWithMinimalEncoding(t *testing.T) {
+	is2 := is.New(t)
+	link := &Hyperlink{URL: "https://example.com"}
+	tests := []struct {
+		name  string
+		input []*StyledText
+		want  string
+	}{
+		{"Blank", []*StyledText{}, ""},
+		{"Plain", []*StyledText{{Label: "Hello"}, {Label: " World"}}, "Hello World"},
+		{"Single", []*StyledText{{Label: "Red", FgCol: Cols[1]}}, "\033[31mRed\033[0m"},
+		{"Same style", []*StyledText{{Label: "Red", FgCol: Cols[1]}, {Label: "Red", FgCol: Cols[1]}}, "\033[31mRedRed\033[0m"},
+		{"Add attribute", []*StyledText{{Label: "Red", FgCol: Cols[1]}, {Label: "Italic", FgCol: Cols[1], Style: Italic}}, "\033[31mRed\033[3mItalic\033[0m"},
+		{"Remove attribute", []*StyledText{{Label: "Italic", FgCol: Cols[1], Style: Italic}, {Label: "Red", FgCol: Cols[1]}}, "\033[3;31mItalic\033[23mRed\033[0m"},
+		{"Change colour", []*StyledText{{Label: "Red", FgCol: Cols[1], Style: Italic}, {Label: "Green", FgCol: Cols[2], Style: Italic}}, "\033[3;31mRed\033[32mGreen\033[0m"},
+		{"Default colour", []*StyledText{{Label: "Red", FgCol: Cols[1], BgCol: Cols[4]}, {Label: "Blue", BgCol: Cols[4]}}, "\033[31;44mRed\033[39mBlue\033[0m"},
+		{"Plain after style", []*StyledText{{Label: "Bold", Style: Bold}, {Label: "Plain"}}, "\033[1mBold\033[0mPlain"},
+		{"Shared off", []*StyledText{{Label: "Both", FgCol: Cols[1], Style: Bold | Faint}, {Label: "Faint", FgCol: Cols[1], Style: Faint}}, "\033[31;1;2mBoth\033[22;2mFaint\033[0m"},
+		{"Reset is shorter", []*StyledText{{Label: "All", Style: Bold | Italic | Underlined | Strikethrough}, {Label: "Blink", Style: Blinking}}, "\033[1;3;4;9mAll\033[0;5mBlink\033[0m"},
+		{"Bold colour", []*StyledText{{Label: "Bold", Style: Bold}, {Label: "Red", FgCol: Cols[9], Style: Bold}}, "\033[1mBold\033[31mRed\033[0m"},
+		{"Bright", []*StyledText{{Label: "Bright", FgCol: Cols[9], Style: Bright}, {Label: "Bold", Style: Bold}}, "\033[91mBright\033[0;1mBold\033[0m"},
+		{"Unknown underline style", []*StyledText{{Label: "Bold", Style: Bold}, {Label: "Line", Underline: 9}}, "\033[1mBold\033[0;4:9mLine\033[0m"},
+		{"Unknown underline style after plain", []*StyledText{{Label: "Plain"}, {Label: "Line", Underline: 9}}, "Plain\033[0;4:9mLine\033[0m"},
+		{"Underline style", []*StyledText{{Label: "Single", Style: Underlined, Underline: UnderlineSingle}, {Label: "Curly", Style: Underlined, Underline: UnderlineCurly}}, "\033[4mSingle\033[4:3mCurly\033[0m"},
+		{"Colons", []*StyledText{{Label: "Orange", FgCol: Cols[208], FgMode: TwoFiveSix}, {Label: "Italic", FgCol: Cols[208], FgMode: TwoFiveSix, Style: Italic}}, "\033[38:5:208mOrange\033[3mItalic\033[0m"},
+		{"Empty label", []*StyledText{{Label: "Red", FgCol: Cols[1]}, {Label: "", Style: Bold}, {Label: "Red", FgCol: Cols[1]}}, "\033[31mRedRed\033[0m"},
+		{"Hyperlink", []*StyledText{{Label: "Link", Hyperlink: link}, {Label: " Bold", Hyperlink: link, Style: Bold}, {Label: " Plain"}}, "\033]8;;https://example.com\033\\Link\033[1m Bold\033]8;;\033\\\033[0m Plain"},
+		{"Hyperlink at end", []*StyledText{{Label: "Link", Hyperlink: link, Style: Bold}}, "\033]8;;https://example.com\033\\\033[1mLink\033[0m\033]8;;\033\\"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := []StringOption{WithMinimalEncoding()}
+			if tt.name == "Colons" {
+				options = append(options, WithColonSeparators())
+			}
+			got := String(tt.input, options...)
+			is2.Equal(got, tt.want)
+		})
+	}
+}
+
+// styleRuns returns the style of each run of text in input,
+// merging runs with the same style
+func styleRuns(t *testing.T, input string) []string {
+	t.Helper()
+	parsed, err := Parse(input)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", input, err)
+	}
+	var result []string
+	previous := ""
+	for _, text := range parsed {
+		if text.Label == "" {
+			continue
+		}
+		style := *text
+		style.Label = ""
+		style.Offset = 0
+		style.Len = 0
+		encoded := style.String()
+		if encoded != previous {
+			result = append(result, encoded)
+			previous = encoded
+		}
+		result = append(result, text.Label)
+	}
+	return result
+}
+
+func TestStringWithMinimalEncodingLooksTheSame(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Styles", "\033[1;31mBold Red\033[3mItalic\033[22mNot bold\033[0mPlain"},
+		{"Bold colours", "\033[31mRed\033[1mBold\033[22;32mGreen\033[1mBold Green"},
+		{"Bright", "\033[91mBright\033[0;1mBold\033[94mBright Blue\033[0m"},
+		{"Backgrounds", "\033[41mRed\033[44;33mBlue\033[49mDefault\033[39m"},
+		{"256 colours", "\033[38;5;208mOrange\033[48;5;18mOn Blue\033[38;5;208;4mUnderlined\033[0m"},
+		{"True colours", "\033[38;2;255;0;0mRed\033[38;2;255;0;0;1mBold\033[38;2;0;255;0mGreen"},
+		{"Underlines", "\033[4mSingle\033[4:3mCurly\033[58;5;1mRed\033[59mPlain\033[24mNone"},
+		{"Shared offs", "\033[5;6mBlink\033[25;6mRapid\033[51;52mFrame\033[54;52mCircle\033[73mUp\033[74mDown"},
+		{"Fonts", "\033[12mFont\033[20mFraktur\033[10mPrimary\033[3mItalic\033[23mNone"},
+		{"Hyperlinks", "\033]8;;https://a.com\033\\\033[1mA\033]8;;https://b.com\033\\B\033]8;;\033\\\033[0mC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := Parse(tt.input)
+			is2.NoErr(err)
+			minimal := String(parsed, WithMinimalEncoding())
+			is2.Equal(styleRuns(t, minimal), styleRuns(t, String(parsed)))
+			is2.True(len(minimal) < len(String(parsed)))
+		})
+	}
+}
+
+// randomANSI returns a random ansi encoded string using every
+// SGR parameter that Parse accepts
+func randomANSI(random *rand.Rand) string {
+	params := []string{
+		"0", "1", "2", "3", "4", "4:0", "4:1", "4:2", "4:3", "4:5", "5", "6", "7", "8", "9",
+		"10", "11", "19", "20", "21", "22", "23", "24", "25", "27", "28", "29",
+		"38;5;9", "38;5;208", "38;2;255;128;0", "38:5:12", "38:2::1:2:3", "39",
+		"48;5;1", "48;5;15", "48;2;0;0;255", "48:5:100", "48:2:4:5:6", "49",
+		"51", "52", "53", "54", "55", "58;5;9", "58;2;1;2;3", "58:5:200", "59", "73", "74", "75",
+	}
+	for code := 0; code < 8; code++ {
+		for _, offset := range []int{30, 40, 90, 100} {
+			params = append(params, strconv.Itoa(offset+code))
+		}
+	}
+	links := []string{
+		"\033]8;;https://example.com\033\\",
+		"\033]8;id=1;https://example.com\033\\",
+		"\033]8;;https://example.org\007",
+		"\033]8;;\033\\",
+	}
+	labels := []string{"a", "bc", "Hello World", "😀", "x\ty"}
+
+	var result strings.Builder
+	for segments := random.Intn(8); segments >= 0; segments-- {
+		switch random.Intn(6) {
+		case 0:
+			result.WriteString(links[random.Intn(len(links))])
+		case 1, 2, 3:
+			selected := make([]string, random.Intn(5)+1)
+			for i := range selected {
+				selected[i] = params[random.Intn(len(params))]
+			}
+			result.WriteString("\033[" + strings.Join(selected, ";") + "m")
+		}
+		if random.Intn(4) > 0 {
+			result.WriteString(labels[random.Intn(len(labels))])
+		}
+	}
+	return result.String()
+}
+
+// sameRuns returns true if a and b have the same text with the same
+// styles, ignoring how the text is split into StyledText
+func sameRuns(a, b []*StyledText) bool {
+	runs := func(input []*StyledText) []*StyledText {
+		var result []*StyledText
+		for _, text := range input {
+			if text.Label == "" {
+				continue
+			}
+			if last := len(result) - 1; last >= 0 && equalStyle(result[last], text) {
+				merged := *result[last]
+				merged.Label += text.Label
+				result[last] = &merged
+				continue
+			}
+			result = append(result, text)
+		}
+		return result
+	}
+	x, y := runs(a), runs(b)
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i].Label != y[i].Label || !equalStyle(x[i], y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStringRoundTripColours(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Bold bright", "\033[1;91mRed"},
+		{"Bold standard", "\033[31;1mRed"},
+		{"Bold removed", "\033[1;31;41mBright\033[22mRed"},
+		{"Bold removed from bright code", "\033[1;91mBright\033[22mBright"},
+		{"Bright background", "\033[31;101mRed"},
+		{"Bright replaced", "\033[91;31mRed"},
+		{"Bright default", "\033[91;39mDefault"},
+		{"Faint bright", "\033[2;91mRed"},
+		{"Faint bright background", "\033[2;101mRed"},
+		{"Bold and faint", "\033[1;2;31;41mRed\033[2;1;31mRed"},
+		{"Mixed maps", "\033[1;31;22;32;1mRed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := Parse(tt.input)
+			is2.NoErr(err)
+			reparsed, err := Parse(String(parsed))
+			is2.NoErr(err)
+			is2.True(sameRuns(parsed, reparsed))
+		})
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	options := map[string][]StringOption{
+		"Default":         nil,
+		"Colons":          {WithColonSeparators()},
+		"Minimal":         {WithMinimalEncoding()},
+		"Minimal, colons": {WithMinimalEncoding(), WithColonSeparators()},
+	}
+	for i := 0; i < 5000; i++ {
+		input := randomANSI(random)
+		parsed, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", input, err)
+		}
+		for name, option := range options {
+			encoded := String(parsed, option...)
+			reparsed, err := Parse(encoded)
+			if err != nil {
+				t.Fatalf("%s: Parse(%q) of %q: %v", name, encoded, input, err)
+			}
+			if !sameRuns(parsed, reparsed) {
+				t.Fatalf("%s: %q encoded as %q parses differently", name, input, encoded)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package ansi
+
+import "fmt"
+
+// ParseError describes a problem with an escape sequence in the input.
+// The Kind is one of the Err values, so errors.Is can be used to check
+// the kind of problem.
+type ParseError struct {
+	// Offset is the offset in bytes into the input where the
+	// sequence begins
+	Offset int
+	// Sequence holds the bytes of the offending sequence. If the
+	// input ends part way through the sequence, it holds the
+	// remainder of the input.
+	Sequence string
+	// Param is the SGR parameter that could not be applied, if any.
+	// Extended colours include all of their parameters, eg. 38;5;300.
+	Param string
+	// Kind is the kind of problem, eg. ErrInvalid256ColSequence
+	Kind error
+}
+
+func (e *ParseError) Error() string {
+	if e.Param != "" {
+		return fmt.Sprintf("%v %q in %q at byte %d", e.Kind, e.Param, e.Sequence, e.Offset)
+	}
+	return fmt.Sprintf("%v %q at byte %d", e.Kind, e.Sequence, e.Offset)
+}
+
+// Unwrap returns the kind of the error
+func (e *ParseError) Unwrap() error {
+	return e.Kind
+}
+
+// copyError copies the Sequence and Param of err, if it is a
+// *ParseError, so that it does not share the memory of the input
+func copyError(err error) error {
+	if parseErr, ok := err.(*ParseError); ok {
+		parseErr.Sequence = cloneString(parseErr.Sequence)
+		parseErr.Param = cloneString(parseErr.Param)
+	}
+	return err
+}
+
+// paramError returns an error for an SGR parameter that could
+// not be applied
+func paramError(kind error, param string) error {
+	return &ParseError{Kind: kind, Param: param}
+}
+
+// sequenceError returns err as a *ParseError for the given sequence
+func sequenceError(err error, sequence string) error {
+	result, ok := err.(*ParseError)
+	if !ok {
+		result = &ParseError{Kind: err}
+	}
+	result.Sequence = sequence
+	return result
+}
+
+// offsetError moves the position of a *ParseError on by offset bytes
+func offsetError(err error, offset int) error {
+	if result, ok := err.(*ParseError); ok {
+		result.Offset += offset
+	}
+	return err
+}
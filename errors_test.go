@@ -0,0 +1,81 @@
+package ansi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	is "github.com/matryer/is"
+)
+
+func TestParseErrors(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+		want  ParseError
+	}{
+		{"Unknown code", "Hello \033[1;99mWorld", ParseError{
+			Offset: 6, Sequence: "\033[1;99m", Param: "99", Kind: ErrInvalid,
+		}},
+		{"Bad 256 colour", "\033[1mBold\033[0m \033[38;5;300mGrey", ParseError{
+			Offset: 13, Sequence: "\033[38;5;300m", Param: "38;5;300", Kind: ErrInvalid256ColSequence,
+		}},
+		{"Bad true colour", "\033[48;2;0;300;0mX", ParseError{
+			Offset: 0, Sequence: "\033[48;2;0;300;0m", Param: "48;2;0;300;0", Kind: ErrInvalidTrueColorSequence,
+		}},
+		{"Bad sub parameter", "X\033[4:9mY", ParseError{
+			Offset: 1, Sequence: "\033[4:9m", Param: "4:9", Kind: ErrInvalid,
+		}},
+		{"Missing terminator", "Hello\033[31", ParseError{
+			Offset: 5, Sequence: "\033[31", Kind: ErrMissingTerminator,
+		}},
+		{"Missing OSC terminator", "Hello\033]8;;http://a", ParseError{
+			Offset: 5, Sequence: "\033]8;;http://a", Kind: ErrMissingTerminator,
+		}},
+		{"Bad byte", "Hello\033[3\x01m", ParseError{
+			Offset: 5, Sequence: "\033[3\x01", Kind: ErrInvalid,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var got *ParseError
+			is2.True(errors.As(err, &got))
+			is2.Equal(*got, tt.want)
+			is2.True(errors.Is(err, tt.want.Kind))
+		})
+	}
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	is2 := is.New(t)
+	_, err := Parse("Hello \033[38;5;300mWorld")
+	is2.Equal(err.Error(), `invalid 256 colour sequence "38;5;300" in "\x1b[38;5;300m" at byte 6`)
+	_, err = Parse("Hello\033[31")
+	is2.Equal(err.Error(), `missing escape terminator "\x1b[31" at byte 5`)
+}
+
+func TestErrorOffsets(t *testing.T) {
+	is2 := is.New(t)
+	input := "one\r\ntwo\033[31m\033[38;5;256mthree"
+	want := strings.Index(input, "\033[38")
+
+	_, err := Tokenize("one\033\x80")
+	var parseErr *ParseError
+	is2.True(errors.As(err, &parseErr))
+	is2.Equal(parseErr.Offset, 3)
+	is2.True(errors.Is(err, ErrInvalid))
+
+	_, err = Collapse(input)
+	is2.True(errors.As(err, &parseErr))
+	is2.Equal(parseErr.Offset, want)
+
+	_, err = decodeAll(NewDecoder(strings.NewReader(input)))
+	is2.True(errors.As(err, &parseErr))
+	is2.Equal(parseErr.Offset, want)
+
+	_, err = Cleanse(input)
+	is2.True(errors.As(err, &parseErr))
+	is2.Equal(parseErr.Offset, want)
+}
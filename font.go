@@ -0,0 +1,222 @@
+package ansi
+
+// The bitmap font used by Render. The glyphs are from the public domain
+// X11 misc-fixed 6x13 font, by way of the Plan 9 port. Each glyph is
+// fontWidth pixels wide and fontHeight pixels tall, with one byte for
+// each row and the leftmost pixel in the highest bit.
+const (
+	fontWidth  = 6
+	fontHeight = 13
+	// fontAscent is the number of rows above the baseline
+	fontAscent = 11
+)
+
+// fontGlyphs holds the glyphs for the printable ASCII characters,
+// followed by the replacement character, which is used for any
+// other character
+var fontGlyphs = [...][fontHeight]byte{
+	// 0x20 ' '
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	// 0x21 '!'
+	{0x00, 0x00, 0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x00, 0x10, 0x00, 0x00},
+	// 0x22 '"'
+	{0x00, 0x00, 0x28, 0x28, 0x28, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	// 0x23 '#'
+	{0x00, 0x00, 0x00, 0x28, 0x28, 0x7c, 0x28, 0x7c, 0x28, 0x28, 0x00, 0x00, 0x00},
+	// 0x24 '$'
+	{0x00, 0x00, 0x00, 0x10, 0x3c, 0x50, 0x38, 0x14, 0x78, 0x10, 0x00, 0x00, 0x00},
+	// 0x25 '%'
+	{0x00, 0x00, 0x44, 0xa4, 0x48, 0x10, 0x10, 0x20, 0x48, 0x94, 0x88, 0x00, 0x00},
+	// 0x26 '&'
+	{0x00, 0x00, 0x00, 0x00, 0x60, 0x90, 0x90, 0x60, 0x94, 0x88, 0x74, 0x00, 0x00},
+	// 0x27 '''
+	{0x00, 0x00, 0x10, 0x10, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	// 0x28 '('
+	{0x00, 0x00, 0x08, 0x10, 0x10, 0x20, 0x20, 0x20, 0x10, 0x10, 0x08, 0x00, 0x00},
+	// 0x29 ')'
+	{0x00, 0x00, 0x20, 0x10, 0x10, 0x08, 0x08, 0x08, 0x10, 0x10, 0x20, 0x00, 0x00},
+	// 0x2a '*'
+	{0x00, 0x00, 0x00, 0x00, 0x48, 0x30, 0xfc, 0x30, 0x48, 0x00, 0x00, 0x00, 0x00},
+	// 0x2b '+'
+	{0x00, 0x00, 0x00, 0x00, 0x10, 0x10, 0x7c, 0x10, 0x10, 0x00, 0x00, 0x00, 0x00},
+	// 0x2c ','
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x38, 0x30, 0x40, 0x00},
+	// 0x2d '-'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x7c, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	// 0x2e '.'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x38, 0x10, 0x00},
+	// 0x2f '/'
+	{0x00, 0x00, 0x04, 0x04, 0x08, 0x08, 0x10, 0x20, 0x20, 0x40, 0x40, 0x00, 0x00},
+	// 0x30 '0'
+	{0x00, 0x00, 0x30, 0x48, 0x84, 0x84, 0x84, 0x84, 0x84, 0x48, 0x30, 0x00, 0x00},
+	// 0x31 '1'
+	{0x00, 0x00, 0x10, 0x30, 0x50, 0x10, 0x10, 0x10, 0x10, 0x10, 0x7c, 0x00, 0x00},
+	// 0x32 '2'
+	{0x00, 0x00, 0x78, 0x84, 0x84, 0x04, 0x08, 0x30, 0x40, 0x80, 0xfc, 0x00, 0x00},
+	// 0x33 '3'
+	{0x00, 0x00, 0xfc, 0x04, 0x08, 0x10, 0x38, 0x04, 0x04, 0x84, 0x78, 0x00, 0x00},
+	// 0x34 '4'
+	{0x00, 0x00, 0x08, 0x18, 0x28, 0x48, 0x88, 0x88, 0xfc, 0x08, 0x08, 0x00, 0x00},
+	// 0x35 '5'
+	{0x00, 0x00, 0xfc, 0x80, 0x80, 0xb8, 0xc4, 0x04, 0x04, 0x84, 0x78, 0x00, 0x00},
+	// 0x36 '6'
+	{0x00, 0x00, 0x38, 0x40, 0x80, 0x80, 0xb8, 0xc4, 0x84, 0x84, 0x78, 0x00, 0x00},
+	// 0x37 '7'
+	{0x00, 0x00, 0xfc, 0x04, 0x08, 0x10, 0x10, 0x20, 0x20, 0x40, 0x40, 0x00, 0x00},
+	// 0x38 '8'
+	{0x00, 0x00, 0x78, 0x84, 0x84, 0x84, 0x78, 0x84, 0x84, 0x84, 0x78, 0x00, 0x00},
+	// 0x39 '9'
+	{0x00, 0x00, 0x78, 0x84, 0x84, 0x8c, 0x74, 0x04, 0x04, 0x08, 0x70, 0x00, 0x00},
+	// 0x3a ':'
+	{0x00, 0x00, 0x00, 0x00, 0x10, 0x38, 0x10, 0x00, 0x00, 0x10, 0x38, 0x10, 0x00},
+	// 0x3b ';'
+	{0x00, 0x00, 0x00, 0x00, 0x10, 0x38, 0x10, 0x00, 0x00, 0x38, 0x30, 0x40, 0x00},
+	// 0x3c '<'
+	{0x00, 0x00, 0x04, 0x08, 0x10, 0x20, 0x40, 0x20, 0x10, 0x08, 0x04, 0x00, 0x00},
+	// 0x3d '='
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0xfc, 0x00, 0x00, 0xfc, 0x00, 0x00, 0x00, 0x00},
+	// 0x3e '>'
+	{0x00, 0x00, 0x40, 0x20, 0x10, 0x08, 0x04, 0x08, 0x10, 0x20, 0x40, 0x00, 0x00},
+	// 0x3f '?'
+	{0x00, 0x00, 0x78, 0x84, 0x84, 0x04, 0x08, 0x10, 0x10, 0x00, 0x10, 0x00, 0x00},
+	// 0x40 '@'
+	{0x00, 0x00, 0x78, 0x84, 0x84, 0x9c, 0xa4, 0xac, 0x94, 0x80, 0x78, 0x00, 0x00},
+	// 0x41 'A'
+	{0x00, 0x00, 0x30, 0x48, 0x84, 0x84, 0x84, 0xfc, 0x84, 0x84, 0x84, 0x00, 0x00},
+	// 0x42 'B'
+	{0x00, 0x00, 0xf8, 0x44, 0x44, 0x44, 0x78, 0x44, 0x44, 0x44, 0xf8, 0x00, 0x00},
+	// 0x43 'C'
+	{0x00, 0x00, 0x78, 0x84, 0x80, 0x80, 0x80, 0x80, 0x80, 0x84, 0x78, 0x00, 0x00},
+	// 0x44 'D'
+	{0x00, 0x00, 0xf8, 0x44, 0x44, 0x44, 0x44, 0x44, 0x44, 0x44, 0xf8, 0x00, 0x00},
+	// 0x45 'E'
+	{0x00, 0x00, 0xfc, 0x80, 0x80, 0x80, 0xf0, 0x80, 0x80, 0x80, 0xfc, 0x00, 0x00},
+	// 0x46 'F'
+	{0x00, 0x00, 0xfc, 0x80, 0x80, 0x80, 0xf0, 0x80, 0x80, 0x80, 0x80, 0x00, 0x00},
+	// 0x47 'G'
+	{0x00, 0x00, 0x78, 0x84, 0x80, 0x80, 0x80, 0x9c, 0x84, 0x8c, 0x74, 0x00, 0x00},
+	// 0x48 'H'
+	{0x00, 0x00, 0x84, 0x84, 0x84, 0x84, 0xfc, 0x84, 0x84, 0x84, 0x84, 0x00, 0x00},
+	// 0x49 'I'
+	{0x00, 0x00, 0x7c, 0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x7c, 0x00, 0x00},
+	// 0x4a 'J'
+	{0x00, 0x00, 0x1c, 0x08, 0x08, 0x08, 0x08, 0x08, 0x08, 0x88, 0x70, 0x00, 0x00},
+	// 0x4b 'K'
+	{0x00, 0x00, 0x84, 0x88, 0x90, 0xa0, 0xc0, 0xa0, 0x90, 0x88, 0x84, 0x00, 0x00},
+	// 0x4c 'L'
+	{0x00, 0x00, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0xfc, 0x00, 0x00},
+	// 0x4d 'M'
+	{0x00, 0x00, 0x84, 0xcc, 0xcc, 0xb4, 0xb4, 0x84, 0x84, 0x84, 0x84, 0x00, 0x00},
+	// 0x4e 'N'
+	{0x00, 0x00, 0x84, 0x84, 0xc4, 0xa4, 0x94, 0x8c, 0x84, 0x84, 0x84, 0x00, 0x00},
+	// 0x4f 'O'
+	{0x00, 0x00, 0x78, 0x84, 0x84, 0x84, 0x84, 0x84, 0x84, 0x84, 0x78, 0x00, 0x00},
+	// 0x50 'P'
+	{0x00, 0x00, 0xf8, 0x84, 0x84, 0x84, 0xf8, 0x80, 0x80, 0x80, 0x80, 0x00, 0x00},
+	// 0x51 'Q'
+	{0x00, 0x00, 0x78, 0x84, 0x84, 0x84, 0x84, 0x84, 0xa4, 0x94, 0x78, 0x04, 0x00},
+	// 0x52 'R'
+	{0x00, 0x00, 0xf8, 0x84, 0x84, 0x84, 0xf8, 0xa0, 0x90, 0x88, 0x84, 0x00, 0x00},
+	// 0x53 'S'
+	{0x00, 0x00, 0x78, 0x84, 0x80, 0x80, 0x78, 0x04, 0x04, 0x84, 0x78, 0x00, 0x00},
+	// 0x54 'T'
+	{0x00, 0x00, 0x7c, 0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x00, 0x00},
+	// 0x55 'U'
+	{0x00, 0x00, 0x84, 0x84, 0x84, 0x84, 0x84, 0x84, 0x84, 0x84, 0x78, 0x00, 0x00},
+	// 0x56 'V'
+	{0x00, 0x00, 0x84, 0x84, 0x84, 0x48, 0x48, 0x48, 0x30, 0x30, 0x30, 0x00, 0x00},
+	// 0x57 'W'
+	{0x00, 0x00, 0x84, 0x84, 0x84, 0x84, 0xb4, 0xb4, 0xcc, 0xcc, 0x84, 0x00, 0x00},
+	// 0x58 'X'
+	{0x00, 0x00, 0x84, 0x84, 0x48, 0x48, 0x30, 0x48, 0x48, 0x84, 0x84, 0x00, 0x00},
+	// 0x59 'Y'
+	{0x00, 0x00, 0x44, 0x44, 0x28, 0x28, 0x10, 0x10, 0x10, 0x10, 0x10, 0x00, 0x00},
+	// 0x5a 'Z'
+	{0x00, 0x00, 0xfc, 0x04, 0x08, 0x10, 0x30, 0x20, 0x40, 0x80, 0xfc, 0x00, 0x00},
+	// 0x5b '['
+	{0x00, 0x78, 0x40, 0x40, 0x40, 0x40, 0x40, 0x40, 0x40, 0x40, 0x40, 0x78, 0x00},
+	// 0x5c '\'
+	{0x00, 0x00, 0x40, 0x40, 0x20, 0x20, 0x10, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00},
+	// 0x5d ']'
+	{0x00, 0x78, 0x08, 0x08, 0x08, 0x08, 0x08, 0x08, 0x08, 0x08, 0x08, 0x78, 0x00},
+	// 0x5e '^'
+	{0x00, 0x00, 0x10, 0x28, 0x44, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	// 0x5f '_'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xfc, 0x00},
+	// 0x60 '`'
+	{0x00, 0x20, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	// 0x61 'a'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x78, 0x04, 0x7c, 0x84, 0x8c, 0x74, 0x00, 0x00},
+	// 0x62 'b'
+	{0x00, 0x00, 0x80, 0x80, 0x80, 0xb8, 0xc4, 0x84, 0x84, 0xc4, 0xb8, 0x00, 0x00},
+	// 0x63 'c'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x78, 0x84, 0x80, 0x80, 0x84, 0x78, 0x00, 0x00},
+	// 0x64 'd'
+	{0x00, 0x00, 0x04, 0x04, 0x04, 0x74, 0x8c, 0x84, 0x84, 0x8c, 0x74, 0x00, 0x00},
+	// 0x65 'e'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x78, 0x84, 0xfc, 0x80, 0x84, 0x78, 0x00, 0x00},
+	// 0x66 'f'
+	{0x00, 0x00, 0x38, 0x44, 0x40, 0x40, 0xf0, 0x40, 0x40, 0x40, 0x40, 0x00, 0x00},
+	// 0x67 'g'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x74, 0x88, 0x88, 0x70, 0x80, 0x78, 0x84, 0x78},
+	// 0x68 'h'
+	{0x00, 0x00, 0x80, 0x80, 0x80, 0xb8, 0xc4, 0x84, 0x84, 0x84, 0x84, 0x00, 0x00},
+	// 0x69 'i'
+	{0x00, 0x00, 0x00, 0x10, 0x00, 0x30, 0x10, 0x10, 0x10, 0x10, 0x7c, 0x00, 0x00},
+	// 0x6a 'j'
+	{0x00, 0x00, 0x00, 0x04, 0x00, 0x0c, 0x04, 0x04, 0x04, 0x04, 0x44, 0x44, 0x38},
+	// 0x6b 'k'
+	{0x00, 0x00, 0x80, 0x80, 0x80, 0x88, 0x90, 0xe0, 0x90, 0x88, 0x84, 0x00, 0x00},
+	// 0x6c 'l'
+	{0x00, 0x00, 0x30, 0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x7c, 0x00, 0x00},
+	// 0x6d 'm'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x68, 0x54, 0x54, 0x54, 0x54, 0x44, 0x00, 0x00},
+	// 0x6e 'n'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0xb8, 0xc4, 0x84, 0x84, 0x84, 0x84, 0x00, 0x00},
+	// 0x6f 'o'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x78, 0x84, 0x84, 0x84, 0x84, 0x78, 0x00, 0x00},
+	// 0x70 'p'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0xb8, 0xc4, 0x84, 0xc4, 0xb8, 0x80, 0x80, 0x80},
+	// 0x71 'q'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x74, 0x8c, 0x84, 0x8c, 0x74, 0x04, 0x04, 0x04},
+	// 0x72 'r'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0xb8, 0x44, 0x40, 0x40, 0x40, 0x40, 0x00, 0x00},
+	// 0x73 's'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x78, 0x84, 0x60, 0x18, 0x84, 0x78, 0x00, 0x00},
+	// 0x74 't'
+	{0x00, 0x00, 0x00, 0x40, 0x40, 0xf0, 0x40, 0x40, 0x40, 0x44, 0x38, 0x00, 0x00},
+	// 0x75 'u'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x84, 0x84, 0x84, 0x84, 0x8c, 0x74, 0x00, 0x00},
+	// 0x76 'v'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x44, 0x44, 0x44, 0x28, 0x28, 0x10, 0x00, 0x00},
+	// 0x77 'w'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x44, 0x44, 0x54, 0x54, 0x54, 0x28, 0x00, 0x00},
+	// 0x78 'x'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x84, 0x48, 0x30, 0x30, 0x48, 0x84, 0x00, 0x00},
+	// 0x79 'y'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x84, 0x84, 0x84, 0x8c, 0x74, 0x04, 0x84, 0x78},
+	// 0x7a 'z'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0xfc, 0x08, 0x10, 0x20, 0x40, 0xfc, 0x00, 0x00},
+	// 0x7b '{'
+	{0x00, 0x1c, 0x20, 0x20, 0x20, 0x10, 0x60, 0x10, 0x20, 0x20, 0x20, 0x1c, 0x00},
+	// 0x7c '|'
+	{0x00, 0x00, 0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x00, 0x00},
+	// 0x7d '}'
+	{0x00, 0x70, 0x08, 0x08, 0x08, 0x10, 0x0c, 0x10, 0x08, 0x08, 0x08, 0x70, 0x00},
+	// 0x7e '~'
+	{0x00, 0x00, 0x24, 0x54, 0x48, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	// U+FFFD replacement character
+	{0x00, 0x00, 0x38, 0x6c, 0x54, 0x74, 0x6c, 0x6c, 0x7c, 0x6c, 0x38, 0x00, 0x00},
+}
+
+// fontReplacement is the index of the replacement character glyph
+const fontReplacement = len(fontGlyphs) - 1
+
+// glyph returns the glyph for the first character of a grapheme
+// cluster, so that combining marks are left out
+func glyph(cluster string) *[fontHeight]byte {
+	if cluster != "" && cluster[0] >= 0x20 && cluster[0] < 0x7f {
+		return &fontGlyphs[cluster[0]-0x20]
+	}
+	return &fontGlyphs[fontReplacement]
+}
@@ -0,0 +1,31 @@
+package ansi
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestGlyph(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{"Space", " ", 0},
+		{"Letter", "A", 'A' - 0x20},
+		{"Tilde", "~", '~' - 0x20},
+		{"Combining mark", "e\u0301", 'e' - 0x20},
+		{"Blank", "", fontReplacement},
+		{"Control", "\x7f", fontReplacement},
+		{"Non ASCII", "\u00e9", fontReplacement},
+		{"Emoji", "😀", fontReplacement},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is2.True(glyph(tt.input) == &fontGlyphs[tt.want])
+		})
+	}
+	is2.Equal(len(fontGlyphs), 0x7f-0x20+1)
+}
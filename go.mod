@@ -4,5 +4,5 @@ go 1.16
 
 require (
 	github.com/matryer/is v1.4.0
-	github.com/rivo/uniseg v0.2.0
+	github.com/rivo/uniseg v0.4.7
 )
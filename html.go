@@ -0,0 +1,260 @@
+package ansi
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// htmlClassPrefix is the prefix of the CSS classes used by HTML
+const htmlClassPrefix = "ansi-"
+
+// htmlRules holds the CSS for the classes of the styles, apart from
+// colours, text decoration lines, Inversed and Invisible
+var htmlRules = []struct{ class, css string }{
+	{"bold", "font-weight:bold"},
+	{"faint", "opacity:0.5"},
+	{"italic", "font-style:italic"},
+	{"fraktur", "font-family:fantasy"},
+	{"underline-double", "text-decoration-style:double"},
+	{"underline-curly", "text-decoration-style:wavy"},
+	{"underline-dotted", "text-decoration-style:dotted"},
+	{"underline-dashed", "text-decoration-style:dashed"},
+	{"blink", "animation:ansi-blink 1s step-end infinite"},
+	{"rapid-blink", "animation:ansi-blink 0.5s step-end infinite"},
+	{"framed", "border:1px solid"},
+	{"encircled", "border:1px solid;border-radius:1em"},
+	{"superscript", "vertical-align:super;font-size:smaller"},
+	{"subscript", "vertical-align:sub;font-size:smaller"},
+}
+
+// htmlLines holds the classes for the text decoration lines, which
+// are combined into a single text-decoration-line property
+var htmlLines = []struct{ class, line string }{
+	{"underline", "underline"},
+	{"strikethrough", "line-through"},
+	{"overlined", "overline"},
+}
+
+// htmlRule returns the CSS for a class in htmlRules
+func htmlRule(class string) string {
+	for _, rule := range htmlRules {
+		if rule.class == class {
+			return rule.css
+		}
+	}
+	return ""
+}
+
+// HTML converts an ansi encoded string to HTML. Each run of styled
+// text is put in a span with a style attribute, or with classes if
+// WithCSSClasses is used, and OSC 8 hyperlinks become links. The text
+// is escaped, and links are only made for http, https, mailto, ftp
+// and file URLs, or relative ones.
+// The result should be placed in an element that keeps white space,
+// such as pre. Blinking text uses the ansi-blink animation, which is
+// included in HTMLStylesheet. Alternative fonts are not rendered.
+// If parsing is unsuccessful, a *ParseError is returned.
+func HTML(input string, options ...HTMLOption) (string, error) {
+	config := newHTMLConfig(options)
+	parseConfig := newParseConfig(config.parseOptions)
+	var state StyledText
+	var result strings.Builder
+	var link *Hyperlink
+	linkOpen := false
+	err := each(input, &state, &parseConfig, func(text StyledText) bool {
+		if !sameHyperlink(link, text.Hyperlink) {
+			if linkOpen {
+				result.WriteString("</a>")
+			}
+			link = text.Hyperlink
+			linkOpen = link != nil && safeURL(link.URL)
+			if linkOpen {
+				result.WriteString(`<a href="` + html.EscapeString(link.URL) + `">`)
+			}
+		}
+		config.writeText(&result, &text)
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+	if linkOpen {
+		result.WriteString("</a>")
+	}
+	return result.String(), nil
+}
+
+// HTMLStylesheet returns the CSS rules for the classes used by HTML
+// with WithCSSClasses, including the colours of the palette in Cols.
+// It also holds the ansi-blink animation used for blinking text.
+func HTMLStylesheet(options ...HTMLOption) string {
+	config := newHTMLConfig(options)
+	var result strings.Builder
+	rule := func(selector, css string) {
+		result.WriteString(selector + "{" + css + "}\n")
+	}
+	rule("@keyframes ansi-blink", "50%{opacity:0}")
+	for _, r := range htmlRules {
+		rule("."+htmlClassPrefix+r.class, r.css)
+	}
+	// One rule for each combination of lines
+	for set := 1; set < 1<<len(htmlLines); set++ {
+		var selector string
+		var lines []string
+		for i, l := range htmlLines {
+			if set&(1<<i) != 0 {
+				selector += "." + htmlClassPrefix + l.class
+				lines = append(lines, l.line)
+			}
+		}
+		rule(selector, "text-decoration-line:"+strings.Join(lines, " "))
+	}
+	// The palette colours come after Inversed, so they replace the
+	// default colours that it swaps
+	rule("."+htmlClassPrefix+"inverse", "color:"+config.background+";background-color:"+config.foreground)
+	for _, col := range Cols {
+		id := strconv.Itoa(col.Id)
+		rule("."+htmlClassPrefix+"fg-"+id, "color:"+colourHex(col))
+		rule("."+htmlClassPrefix+"bg-"+id, "background-color:"+colourHex(col))
+		rule("."+htmlClassPrefix+"ul-"+id, "text-decoration-color:"+colourHex(col))
+	}
+	rule("."+htmlClassPrefix+"invisible", "color:transparent")
+	return result.String()
+}
+
+// writeText writes the HTML for text to result
+func (c *htmlConfig) writeText(result *strings.Builder, text *StyledText) {
+	label := html.EscapeString(text.Label)
+	classes, styles := c.attributes(text)
+	if len(classes) == 0 && len(styles) == 0 {
+		result.WriteString(label)
+		return
+	}
+	result.WriteString("<span")
+	if len(classes) > 0 {
+		result.WriteString(` class="` + htmlClassPrefix + strings.Join(classes, " "+htmlClassPrefix) + `"`)
+	}
+	if len(styles) > 0 {
+		result.WriteString(` style="` + html.EscapeString(strings.Join(styles, ";")) + `"`)
+	}
+	result.WriteString(">" + label + "</span>")
+}
+
+// attributes returns the classes, without the prefix, and the CSS
+// declarations for the style of s
+func (c *htmlConfig) attributes(s *StyledText) (classes []string, styles []string) {
+	add := func(class string) {
+		if c.classes {
+			classes = append(classes, class)
+			return
+		}
+		styles = append(styles, htmlRule(class))
+	}
+	// addColour uses a class for colours from the palette
+	addColour := func(class string, property string, col *Col) {
+		if c.classes && col.Id >= 0 && col.Id < len(Cols) && *col == *Cols[col.Id] {
+			classes = append(classes, class+"-"+strconv.Itoa(col.Id))
+			return
+		}
+		styles = append(styles, property+":"+colourHex(col))
+	}
+
+	flags := []struct {
+		set   bool
+		class string
+	}{
+		{s.Bold(), "bold"},
+		{s.Faint(), "faint"},
+		{s.Italic(), "italic"},
+		{s.Fraktur(), "fraktur"},
+		{s.Blinking(), "blink"},
+		{s.RapidBlinking(), "rapid-blink"},
+		{s.Framed(), "framed"},
+		{s.Encircled(), "encircled"},
+		{s.Superscript(), "superscript"},
+		{s.Subscript(), "subscript"},
+	}
+	for _, flag := range flags {
+		if flag.set {
+			add(flag.class)
+		}
+	}
+
+	var lines []string
+	for i, set := range []bool{s.Underlined() || s.Underline != UnderlineNone, s.Strikethrough(), s.Overlined()} {
+		if !set {
+			continue
+		}
+		if c.classes {
+			classes = append(classes, htmlLines[i].class)
+		}
+		lines = append(lines, htmlLines[i].line)
+	}
+	if len(lines) > 0 && !c.classes {
+		styles = append(styles, "text-decoration-line:"+strings.Join(lines, " "))
+	}
+	switch s.Underline {
+	case UnderlineDouble:
+		add("underline-double")
+	case UnderlineCurly:
+		add("underline-curly")
+	case UnderlineDotted:
+		add("underline-dotted")
+	case UnderlineDashed:
+		add("underline-dashed")
+	}
+	if s.UlCol != nil {
+		addColour("ul", "text-decoration-color", s.UlCol)
+	}
+
+	fg, bg := s.FgCol, s.BgCol
+	if s.Inversed() {
+		fg, bg = bg, fg
+		if c.classes {
+			classes = append(classes, "inverse")
+		} else {
+			// Swap the default colours
+			if fg == nil {
+				styles = append(styles, "color:"+c.background)
+			}
+			if bg == nil {
+				styles = append(styles, "background-color:"+c.foreground)
+			}
+		}
+	}
+	if s.Invisible() {
+		if c.classes {
+			classes = append(classes, "invisible")
+		} else {
+			styles = append(styles, "color:transparent")
+		}
+	} else if fg != nil {
+		addColour("fg", "color", fg)
+	}
+	if bg != nil {
+		addColour("bg", "background-color", bg)
+	}
+	return classes, styles
+}
+
+// colourHex returns the CSS hex colour for col
+func colourHex(col *Col) string {
+	return fmt.Sprintf("#%02x%02x%02x", col.Rgb.R, col.Rgb.G, col.Rgb.B)
+}
+
+// safeURL returns true if url is relative or uses a scheme
+// that is safe to link to
+func safeURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https", "mailto", "ftp", "file":
+		return true
+	}
+	return false
+}
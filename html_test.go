@@ -0,0 +1,119 @@
+package ansi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestHTML(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Blank", "", ""},
+		{"Plain", "Hello World", "Hello World"},
+		{"Escaped", "<b>'Tom' & \"Jerry\"</b>", "&lt;b&gt;&#39;Tom&#39; &amp; &#34;Jerry&#34;&lt;/b&gt;"},
+		{"Colours", "\033[1;31;44mRed\033[0m", `<span style="font-weight:bold;color:#ff0000;background-color:#0000ff">Red</span>`},
+		{"256 colours", "\033[38;5;208mOrange", `<span style="color:#ff8700">Orange</span>`},
+		{"True colours", "\033[48;2;1;2;3mDark", `<span style="background-color:#010203">Dark</span>`},
+		{"Styles", "\033[2;3;20mText", `<span style="opacity:0.5;font-style:italic;font-family:fantasy">Text</span>`},
+		{"Lines", "\033[4;9;53mText", `<span style="text-decoration-line:underline line-through overline">Text</span>`},
+		{"Underline style", "\033[4:3;58;5;1mText", `<span style="text-decoration-line:underline;text-decoration-style:wavy;text-decoration-color:#800000">Text</span>`},
+		{"Blinking", "\033[5mSlow\033[25;6mFast", `<span style="animation:ansi-blink 1s step-end infinite">Slow</span><span style="animation:ansi-blink 0.5s step-end infinite">Fast</span>`},
+		{"Frames", "\033[51mFramed\033[54;52mEncircled", `<span style="border:1px solid">Framed</span><span style="border:1px solid;border-radius:1em">Encircled</span>`},
+		{"Positions", "\033[73mUp\033[74mDown", `<span style="vertical-align:super;font-size:smaller">Up</span><span style="vertical-align:sub;font-size:smaller">Down</span>`},
+		{"Inversed", "\033[7mText", `<span style="color:#000000;background-color:#c0c0c0">Text</span>`},
+		{"Inversed colours", "\033[7;31;42mText", `<span style="color:#008000;background-color:#800000">Text</span>`},
+		{"Invisible", "\033[8;31;42mText", `<span style="color:transparent;background-color:#008000">Text</span>`},
+		{"Hyperlink", "\033]8;;https://example.com/?a=1&b=2\033\\Link\033]8;;\033\\ Text", `<a href="https://example.com/?a=1&amp;b=2">Link</a> Text`},
+		{"Styled hyperlink", "\033]8;;https://example.com\033\\\033[1mBold\033[0m Plain", `<a href="https://example.com"><span style="font-weight:bold">Bold</span> Plain</a>`},
+		{"Unsafe hyperlink", "\033]8;;javascript:alert(1)\033\\Link\033]8;;\033\\", "Link"},
+		{"Invalid hyperlink", "\033]8;;http://[::1\033\\Link\033]8;;\033\\", "Link"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HTML(tt.input)
+			is2.NoErr(err)
+			is2.Equal(got, tt.want)
+		})
+	}
+}
+
+func TestHTMLWithCSSClasses(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Plain", "Hello World", "Hello World"},
+		{"Colours", "\033[1;31;44mRed\033[0m", `<span class="ansi-bold ansi-fg-9 ansi-bg-12">Red</span>`},
+		{"True colours", "\033[3;38;2;1;2;3mDark", `<span class="ansi-italic" style="color:#010203">Dark</span>`},
+		{"Lines", "\033[21;9mText", `<span class="ansi-underline ansi-strikethrough ansi-underline-double">Text</span>`},
+		{"Underline colour", "\033[4;58;5;1mText", `<span class="ansi-underline ansi-ul-1">Text</span>`},
+		{"Underline styles", "\033[4:3mA\033[4:4mB\033[4:5mC", `<span class="ansi-underline ansi-underline-curly">A</span><span class="ansi-underline ansi-underline-dotted">B</span><span class="ansi-underline ansi-underline-dashed">C</span>`},
+		{"Blinking", "\033[5mSlow\033[25;6mFast", `<span class="ansi-blink">Slow</span><span class="ansi-rapid-blink">Fast</span>`},
+		{"Inversed", "\033[7;31mText", `<span class="ansi-inverse ansi-bg-1">Text</span>`},
+		{"Invisible", "\033[8;31;42mText", `<span class="ansi-invisible ansi-bg-2">Text</span>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HTML(tt.input, WithCSSClasses())
+			is2.NoErr(err)
+			is2.Equal(got, tt.want)
+		})
+	}
+}
+
+func TestHTMLOptions(t *testing.T) {
+	is2 := is.New(t)
+
+	// Default colours are swapped by Inversed
+	got, err := HTML("\033[7mText", WithDefaultColours("#fff", "#111"))
+	is2.NoErr(err)
+	is2.Equal(got, `<span style="color:#111;background-color:#fff">Text</span>`)
+
+	// Colours from another palette use a style attribute
+	palette := []*Col{{Rgb: Rgb{R: 1, G: 2, B: 3}}}
+	got, err = HTML("\033[30mText", WithCSSClasses(), WithParseOptions(WithPalette(palette)))
+	is2.NoErr(err)
+	is2.Equal(got, `<span style="color:#010203">Text</span>`)
+
+	// Parse errors are returned
+	_, err = HTML("\033[38;5;300mText")
+	is2.True(errors.Is(err, ErrInvalid256ColSequence))
+	got, err = HTML("\033[99mText", WithParseOptions(WithIgnoreInvalidCodes()))
+	is2.NoErr(err)
+	is2.Equal(got, "Text")
+}
+
+func TestHTMLRule(t *testing.T) {
+	is2 := is.New(t)
+	is2.Equal(htmlRule("bold"), "font-weight:bold")
+	is2.Equal(htmlRule("unknown"), "")
+}
+
+func TestHTMLStylesheet(t *testing.T) {
+	is2 := is.New(t)
+	stylesheet := HTMLStylesheet(WithDefaultColours("#fff", "#111"))
+	for _, rule := range []string{
+		"@keyframes ansi-blink{50%{opacity:0}}\n",
+		".ansi-bold{font-weight:bold}\n",
+		".ansi-rapid-blink{animation:ansi-blink 0.5s step-end infinite}\n",
+		".ansi-underline.ansi-strikethrough.ansi-overlined{text-decoration-line:underline line-through overline}\n",
+		".ansi-inverse{color:#111;background-color:#fff}\n",
+		".ansi-fg-9{color:#ff0000}\n",
+		".ansi-bg-255{background-color:#eeeeee}\n",
+		".ansi-ul-1{text-decoration-color:#800000}\n",
+		".ansi-invisible{color:transparent}\n",
+	} {
+		is2.True(strings.Contains(stylesheet, rule))
+	}
+	// Palette colours replace the colours set by Inversed
+	is2.True(strings.Index(stylesheet, ".ansi-inverse") < strings.Index(stylesheet, ".ansi-fg-0"))
+}
@@ -0,0 +1,139 @@
+package ansi
+
+import (
+	"github.com/rivo/uniseg"
+)
+
+// layoutCell is a single user-perceived character laid out
+// in the cells of a terminal
+type layoutCell struct {
+	char string
+	// width is the number of columns the character takes up
+	width int
+	style *StyledText
+}
+
+// layout parses input and lays out the text in lines of cells, using
+// the display width of each grapheme cluster. Tabs move to the next
+// multiple of 8 columns and other control characters are removed.
+func layout(input string, options []ParseOption) ([][]layoutCell, error) {
+	var state StyledText
+	config := newParseConfig(options)
+	lines := [][]layoutCell{nil}
+	column := 0
+	err := each(input, &state, &config, func(text StyledText) bool {
+		style := &text
+		label := text.Label
+		clusterState := -1
+		for len(label) > 0 {
+			var cluster string
+			var width int
+			cluster, label, width, clusterState = uniseg.FirstGraphemeClusterInString(label, clusterState)
+			last := len(lines) - 1
+			switch {
+			case cluster == "\n" || cluster == "\r\n":
+				lines = append(lines, nil)
+				column = 0
+			case cluster == "\t":
+				for next := (column/8 + 1) * 8; column < next; column++ {
+					lines[last] = append(lines[last], layoutCell{char: " ", width: 1, style: style})
+				}
+			case cluster[0] < 0x20 || cluster[0] == 0x7f || width == 0:
+				// Control characters and zero width characters take up no space
+			default:
+				lines[last] = append(lines[last], layoutCell{char: cluster, width: width, style: style})
+				column += width
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	// A final newline does not start another line
+	if len(lines) > 1 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines, nil
+}
+
+// layoutWidth returns the number of columns taken up by the widest line
+func layoutWidth(lines [][]layoutCell) int {
+	result := 0
+	for _, line := range lines {
+		width := 0
+		for _, cell := range line {
+			width += cell.width
+		}
+		if width > result {
+			result = width
+		}
+	}
+	return result
+}
+
+// cellColours returns the colours used to draw text in the style of
+// s. The background is nil when the terminal background is used.
+func (c *renderConfig) cellColours(s *StyledText) (fg *Col, bg *Col) {
+	fg, bg = s.FgCol, s.BgCol
+	if s.Inversed() {
+		fg, bg = bg, fg
+		if fg == nil {
+			fg = c.background
+		}
+		if bg == nil {
+			bg = c.foreground
+		}
+	}
+	if fg == nil {
+		fg = c.foreground
+	}
+	if s.Faint() {
+		// Faint text is half way to the background
+		background := bg
+		if background == nil {
+			background = c.background
+		}
+		fg = &Col{Rgb: Rgb{
+			R: uint8((int(fg.Rgb.R) + int(background.Rgb.R)) / 2),
+			G: uint8((int(fg.Rgb.G) + int(background.Rgb.G)) / 2),
+			B: uint8((int(fg.Rgb.B) + int(background.Rgb.B)) / 2),
+		}}
+	}
+	return fg, bg
+}
+
+// cellRun is a run of neighbouring cells in a line
+type cellRun struct {
+	// column is the column of the first cell and width is the
+	// number of columns taken up by the run
+	column, width int
+	cells         []layoutCell
+}
+
+// cellRuns splits line into runs of neighbouring cells that have the
+// same key. Cells for which key returns false are not in any run.
+func cellRuns(line []layoutCell, key func(cell *layoutCell, column int) (string, bool)) []cellRun {
+	var result []cellRun
+	previous := ""
+	inRun := false
+	column := 0
+	for i := range line {
+		cell := &line[i]
+		current, ok := key(cell, column)
+		switch {
+		case !ok:
+			inRun = false
+		case inRun && current == previous:
+			last := &result[len(result)-1]
+			last.width += cell.width
+			last.cells = append(last.cells, *cell)
+		default:
+			result = append(result, cellRun{column: column, width: cell.width, cells: []layoutCell{*cell}})
+			inRun = true
+		}
+		previous = current
+		column += cell.width
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+package ansi
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLayout(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+		width int
+	}{
+		{"Blank", "", []string{""}, 0},
+		{"Lines", "ab\r\ncd\n", []string{"ab", "cd"}, 2},
+		{"Blank lines", "\n\na", []string{"", "", "a"}, 1},
+		{"Tabs", "a\tb\tc", []string{"a       b       c"}, 17},
+		{"Control characters", "a\rb\bc\033[1md", []string{"abcd"}, 4},
+		{"Wide characters", "😀中", []string{"😀中"}, 4},
+		{"Combining characters", "é​!", []string{"é!"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := layout(tt.input, nil)
+			is2.NoErr(err)
+			var got []string
+			for _, line := range lines {
+				text := ""
+				for _, cell := range line {
+					text += cell.char
+				}
+				got = append(got, text)
+			}
+			is2.Equal(got, tt.want)
+			is2.Equal(layoutWidth(lines), tt.width)
+		})
+	}
+}
+
+func TestCellRuns(t *testing.T) {
+	is2 := is.New(t)
+	lines, err := layout("\033[31mab\033[32mc😀\033[0md", nil)
+	is2.NoErr(err)
+	runs := cellRuns(lines[0], func(cell *layoutCell, column int) (string, bool) {
+		if cell.style.FgCol == nil {
+			return "", false
+		}
+		return cell.style.FgCol.Name, true
+	})
+	is2.Equal(len(runs), 2)
+	is2.Equal(runs[0].column, 0)
+	is2.Equal(runs[0].width, 2)
+	is2.Equal(runs[1].column, 2)
+	is2.Equal(runs[1].width, 3)
+	is2.Equal(len(runs[1].cells), 2)
+}
@@ -5,6 +5,14 @@ type ParseOption struct {
 	ignoreUnexpectedCode bool
 	ansiForegroundColor  string
 	ansiBackgroundColor  string
+	overstrike           bool
+	recovery             bool
+	recoveryHandler      func(err *ParseError)
+	palette              []*Col
+	setBoldAsBright      bool
+	boldAsBright         bool
+	// colours is the colour table owned by a Parser
+	colours *colourTable
 }
 
 // WithIgnoreInvalidCodes disables returning an error on invalid ANSI code.
@@ -14,12 +22,349 @@ func WithIgnoreInvalidCodes() ParseOption {
 
 // WithDefaultForegroundColor specifies default foreground code (ANSI 39).
 // See ColourMap variable and foreground color codes 30-37.
+// Without this option, ANSI 39 selects the terminal's default
+// foreground colour, which is represented by a nil FgCol.
 func WithDefaultForegroundColor(ansiColor string) ParseOption {
 	return ParseOption{ansiForegroundColor: ansiColor}
 }
 
-// WithDefaultBackgroundColor specifies default foreground code (ANSI 49).
+// WithDefaultBackgroundColor specifies default background code (ANSI 49).
 // See ColourMap variable and foreground color codes 30-37.
+// Without this option, ANSI 49 selects the terminal's default
+// background colour, which is represented by a nil BgCol.
 func WithDefaultBackgroundColor(ansiColor string) ParseOption {
 	return ParseOption{ansiBackgroundColor: ansiColor}
 }
+
+// WithOverstrike decodes the overstrike sequences used by nroff,
+// man and less: "X\bX" is parsed as a Bold X and "_\bX" as an
+// Underlined X.
+func WithOverstrike() ParseOption {
+	return ParseOption{overstrike: true}
+}
+
+// WithRecovery drops malformed and unterminated sequences instead of
+// returning an error, so that parsing always finishes. Unlike
+// WithIgnoreInvalidCodes, which skips unknown SGR parameters, the
+// whole of a malformed sequence is dropped and the style is left as
+// it was before the sequence.
+func WithRecovery() ParseOption {
+	return ParseOption{recovery: true}
+}
+
+// WithRecoveryHandler enables recovery, as WithRecovery does, and
+// calls handler with the error for each sequence that is dropped.
+func WithRecoveryHandler(handler func(err *ParseError)) ParseOption {
+	return ParseOption{recovery: true, recoveryHandler: handler}
+}
+
+// WithPalette sets the colours used for the 256 colour indexes.
+// cols[i] is used for index i, and the first 16 colours are also used
+// for the 16 colour codes, eg. cols[1] for 31. Indexes beyond the end
+// of cols use the package level Cols. The colours are copied, so
+// later changes to cols have no effect.
+// A Parser created with NewParser copies the palette once, so is
+// faster than passing WithPalette to the package level functions.
+func WithPalette(cols []*Col) ParseOption {
+	return ParseOption{palette: cols}
+}
+
+// WithBoldAsBright selects whether bold text in one of the 8 standard
+// colours uses the bright version of the colour, as many terminals do.
+// It is enabled by default. The colour is chosen when the colour code
+// is applied, so text that is made bold after its colour is set, eg.
+// with 31;1, keeps the standard colour, and text that is no longer
+// bold or faint, with 22, goes back to the standard colour.
+func WithBoldAsBright(enabled bool) ParseOption {
+	return ParseOption{setBoldAsBright: true, boldAsBright: enabled}
+}
+
+// ignoreUnexpectedCodes returns true if any of the options
+// disables returning an error on invalid ANSI code.
+func ignoreUnexpectedCodes(options []ParseOption) bool {
+	for _, option := range options {
+		if option.ignoreUnexpectedCode {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeOverstrike returns true if any of the options
+// enables decoding overstrike sequences.
+func decodeOverstrike(options []ParseOption) bool {
+	for _, option := range options {
+		if option.overstrike {
+			return true
+		}
+	}
+	return false
+}
+
+// recoverFrom passes err to any recovery handlers in the options,
+// and returns true if any of the options enables recovery.
+func recoverFrom(err error, options []ParseOption) bool {
+	result := false
+	for _, option := range options {
+		if !option.recovery {
+			continue
+		}
+		result = true
+		if parseErr, ok := err.(*ParseError); ok && option.recoveryHandler != nil {
+			option.recoveryHandler(parseErr)
+		}
+	}
+	return result
+}
+
+// boldAsBright returns the bold as bright setting of the last of the
+// options to set it, or def if none of them do.
+func boldAsBright(options []ParseOption, def bool) bool {
+	result := def
+	for _, option := range options {
+		if option.setBoldAsBright {
+			result = option.boldAsBright
+		}
+	}
+	return result
+}
+
+// parseConfig holds the settings selected by a set of options,
+// so that the options are only read once for each parse
+type parseConfig struct {
+	options               []ParseOption
+	ignoreUnexpectedCodes bool
+	foregroundColour      string
+	backgroundColour      string
+	overstrike            bool
+	recovery              bool
+	colours               colourTable
+	// regular, bold and faint are the colour maps from colours
+	regular, bold, faint map[string]*Col
+}
+
+// newParseConfig reads the settings selected by the options
+func newParseConfig(options []ParseOption) parseConfig {
+	result := parseConfig{
+		options:               options,
+		ignoreUnexpectedCodes: ignoreUnexpectedCodes(options),
+		overstrike:            decodeOverstrike(options),
+		colours:               coloursFor(options),
+	}
+	result.regular = result.colours.colourMap["Regular"]
+	result.bold = result.colours.colourMap["Bold"]
+	result.faint = result.colours.colourMap["Faint"]
+	for _, option := range options {
+		if result.foregroundColour == "" {
+			result.foregroundColour = option.ansiForegroundColor
+		}
+		if result.backgroundColour == "" {
+			result.backgroundColour = option.ansiBackgroundColor
+		}
+		result.recovery = result.recovery || option.recovery
+	}
+	return result
+}
+
+// colourMap returns the map used by the 16 colour codes for text with
+// the given style. Bold text uses the Bold map, even when it is also
+// faint.
+func (c *parseConfig) colourMap(style TextStyle) map[string]*Col {
+	switch {
+	case style&Bold != 0:
+		return c.bold
+	case style&Faint != 0:
+		return c.faint
+	}
+	return c.regular
+}
+
+// recoverFrom passes err to any recovery handlers, and
+// returns true if recovery is enabled
+func (c *parseConfig) recoverFrom(err error) bool {
+	if !c.recovery {
+		return false
+	}
+	return recoverFrom(err, c.options)
+}
+
+// StringOption specifies an option for building ANSI strings.
+type StringOption struct {
+	colonSeparators bool
+	minimalEncoding bool
+}
+
+// WithColonSeparators uses the ITU T.416 colon separated form for
+// 256 and true colours, eg. 38:5:208 and 38:2::255:0:0.
+func WithColonSeparators() StringOption {
+	return StringOption{colonSeparators: true}
+}
+
+// useColonSeparators returns true if any of the options
+// selects the colon separated form.
+func useColonSeparators(options []StringOption) bool {
+	for _, option := range options {
+		if option.colonSeparators {
+			return true
+		}
+	}
+	return false
+}
+
+// WithMinimalEncoding only writes the SGR parameters for the
+// attributes that change from one StyledText to the next, instead of
+// resetting and selecting the whole style for each one. Attributes
+// are turned off with their own parameters, eg. 22 or 39, and a reset
+// is only used when it is shorter or there is no other way to turn an
+// attribute off. The result looks the same in a terminal.
+func WithMinimalEncoding() StringOption {
+	return StringOption{minimalEncoding: true}
+}
+
+// useMinimalEncoding returns true if any of the options
+// selects minimal encoding.
+func useMinimalEncoding(options []StringOption) bool {
+	for _, option := range options {
+		if option.minimalEncoding {
+			return true
+		}
+	}
+	return false
+}
+
+// HTMLOption specifies an option for rendering HTML.
+type HTMLOption struct {
+	classes      bool
+	foreground   string
+	background   string
+	parseOptions []ParseOption
+}
+
+// WithCSSClasses uses CSS classes, eg. "ansi-bold" and "ansi-fg-1",
+// instead of inline style attributes. The rules for the classes are
+// returned by HTMLStylesheet. True colours, which have no class,
+// still use a style attribute.
+func WithCSSClasses() HTMLOption {
+	return HTMLOption{classes: true}
+}
+
+// WithDefaultColours sets the CSS colours of the terminal's default
+// foreground and background, which are swapped for Inversed text.
+// Without this option, the colours of Cols[7] and Cols[0] are used.
+func WithDefaultColours(foreground, background string) HTMLOption {
+	return HTMLOption{foreground: foreground, background: background}
+}
+
+// WithParseOptions sets the options used to parse the input.
+func WithParseOptions(options ...ParseOption) HTMLOption {
+	return HTMLOption{parseOptions: options}
+}
+
+// htmlConfig holds the settings selected by a set of HTML options
+type htmlConfig struct {
+	classes      bool
+	foreground   string
+	background   string
+	parseOptions []ParseOption
+}
+
+// newHTMLConfig reads the settings selected by the options
+func newHTMLConfig(options []HTMLOption) htmlConfig {
+	result := htmlConfig{
+		foreground: colourHex(Cols[7]),
+		background: colourHex(Cols[0]),
+	}
+	for _, option := range options {
+		result.classes = result.classes || option.classes
+		if option.foreground != "" {
+			result.foreground = option.foreground
+		}
+		if option.background != "" {
+			result.background = option.background
+		}
+		result.parseOptions = append(result.parseOptions, option.parseOptions...)
+	}
+	return result
+}
+
+// RenderOption specifies an option for rendering terminal screenshots
+// with SVG or Render.
+type RenderOption struct {
+	window       bool
+	title        string
+	foreground   *Col
+	background   *Col
+	palette      []*Col
+	parseOptions []ParseOption
+}
+
+// WithWindow draws the text in a terminal window, with the given
+// title. The title may be empty.
+func WithWindow(title string) RenderOption {
+	return RenderOption{window: true, title: title}
+}
+
+// WithTerminalColours sets the terminal's default foreground and
+// background colours. Without this option, the colours at index 7
+// and 0 of the palette are used.
+func WithTerminalColours(foreground, background *Col) RenderOption {
+	return RenderOption{foreground: foreground, background: background}
+}
+
+// WithRenderPalette sets the colours used for the 256 colour indexes,
+// in the same way as WithPalette.
+func WithRenderPalette(cols []*Col) RenderOption {
+	return RenderOption{palette: cols}
+}
+
+// WithRenderParseOptions sets the options used to parse the input.
+func WithRenderParseOptions(options ...ParseOption) RenderOption {
+	return RenderOption{parseOptions: options}
+}
+
+// renderConfig holds the settings selected by a set of render options
+type renderConfig struct {
+	window       bool
+	title        string
+	foreground   *Col
+	background   *Col
+	parseOptions []ParseOption
+}
+
+// newRenderConfig reads the settings selected by the options
+func newRenderConfig(options []RenderOption) renderConfig {
+	var result renderConfig
+	var palette []*Col
+	for _, option := range options {
+		if option.window {
+			result.window = true
+			result.title = option.title
+		}
+		if option.foreground != nil {
+			result.foreground = option.foreground
+		}
+		if option.background != nil {
+			result.background = option.background
+		}
+		if option.palette != nil {
+			palette = option.palette
+		}
+		result.parseOptions = append(result.parseOptions, option.parseOptions...)
+	}
+	if palette != nil {
+		result.parseOptions = append(result.parseOptions, WithPalette(palette))
+	}
+	paletteColour := func(index int) *Col {
+		if index < len(palette) {
+			return palette[index]
+		}
+		return Cols[index]
+	}
+	if result.foreground == nil {
+		result.foreground = paletteColour(7)
+	}
+	if result.background == nil {
+		result.background = paletteColour(0)
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package ansi
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// overstrikeText decodes the overstrike sequences in the label of text.
+// A character struck over itself is bold and a character struck over
+// an underscore is underlined. The returned segments cover the same
+// bytes of the input as text.
+func overstrikeText(text *StyledText) []*StyledText {
+	var result []*StyledText
+	var current *StyledText
+	var label strings.Builder
+	offset := text.Offset
+	escapeCodeLen := text.Len - len(text.Label)
+	start := 0
+	input := text.Label
+	index := 0
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Label = label.String()
+		current.Offset = offset
+		current.Len = index - start + escapeCodeLen
+		result = append(result, current)
+		offset += current.Len
+		escapeCodeLen = 0
+		start = index
+		current = nil
+		label.Reset()
+	}
+	for index < len(input) {
+		char, size := utf8.DecodeRuneInString(input[index:])
+		end := index + size
+		bold, underlined := false, false
+		// Apply each following backspace and character
+		for end+1 < len(input) && input[end] == '\b' {
+			next, nextSize := utf8.DecodeRuneInString(input[end+1:])
+			switch {
+			case next == char:
+				bold = true
+			case char == '_':
+				underlined = true
+				char = next
+			case next == '_':
+				underlined = true
+			default:
+				char = next
+			}
+			end += 1 + nextSize
+		}
+		style := *text
+		if bold {
+			style.Style |= Bold
+		}
+		if underlined && style.Underline == UnderlineNone {
+			style.setUnderline(UnderlineSingle)
+		}
+		if current == nil || current.Style != style.Style || current.Underline != style.Underline {
+			flush()
+			current = &style
+		}
+		label.WriteRune(char)
+		index = end
+	}
+	flush()
+	return result
+}
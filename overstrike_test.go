@@ -0,0 +1,70 @@
+package ansi
+
+import (
+	"testing"
+
+	is "github.com/matryer/is"
+)
+
+func TestParseOverstrike(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+		want  []*StyledText
+	}{
+		{"No overstrike", "Hello", []*StyledText{
+			{Label: "Hello", Len: 5},
+		}},
+		{"Bold", "N\bNA\bAM\bME\bE", []*StyledText{
+			{Label: "NAME", Style: Bold, Len: 12},
+		}},
+		{"Underlined", "_\bf_\bi_\bl_\be", []*StyledText{
+			{Label: "file", Style: Underlined, Underline: UnderlineSingle, Len: 12},
+		}},
+		{"Mixed", "ls -\b-a\ba _\bd_\bi_\br", []*StyledText{
+			{Label: "ls ", Len: 3},
+			{Label: "-a", Style: Bold, Offset: 3, Len: 6},
+			{Label: " ", Offset: 9, Len: 1},
+			{Label: "dir", Style: Underlined, Underline: UnderlineSingle, Offset: 10, Len: 9},
+		}},
+		{"Bold and underlined", "_\bX\bX", []*StyledText{
+			{Label: "X", Style: Bold | Underlined, Underline: UnderlineSingle, Len: 5},
+		}},
+		{"Underscore struck over", "X\b_", []*StyledText{
+			{Label: "X", Style: Underlined, Underline: UnderlineSingle, Len: 3},
+		}},
+		{"Overwritten", "a\bb", []*StyledText{
+			{Label: "b", Len: 3},
+		}},
+		{"Multibyte", "é\bé", []*StyledText{
+			{Label: "é", Style: Bold, Len: 5},
+		}},
+		{"Trailing backspace", "a\b", []*StyledText{
+			{Label: "a\b", Len: 2},
+		}},
+		{"With escape codes", "\033[31mB\bB\033[0mx", []*StyledText{
+			{Label: "B", FgCol: Cols[1], Style: Bold, Len: 8},
+			{Label: "x", Offset: 8, Len: 5},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input, WithOverstrike())
+			is2.NoErr(err)
+			is2.Equal(len(got), len(tt.want))
+			for index, w := range tt.want {
+				is2.Equal(*got[index], *w)
+			}
+		})
+	}
+}
+
+func TestParseWithoutOverstrike(t *testing.T) {
+	is2 := is.New(t)
+	got, err := Parse("N\bN")
+	is2.NoErr(err)
+	is2.Equal(len(got), 1)
+	is2.Equal(got[0].Label, "N\bN")
+	is2.Equal(got[0].Style, TextStyle(0))
+}
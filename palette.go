@@ -0,0 +1,103 @@
+package ansi
+
+// colourTable holds the colours used when parsing
+type colourTable struct {
+	// cols holds the colours for the 256 colour indexes
+	cols []*Col
+	// colourMap maps the 16 colour codes to colours, in the
+	// same way as the package level ColourMap
+	colourMap map[string]map[string]*Col
+}
+
+// coloursFor returns the colour table owned by a Parser, if the
+// options include one, or a table using the package level Cols and
+// ColourMap.
+func coloursFor(options []ParseOption) colourTable {
+	result := colourTable{cols: Cols, colourMap: ColourMap}
+	for _, option := range options {
+		if option.colours != nil {
+			return *option.colours
+		}
+	}
+	for _, option := range options {
+		if option.palette != nil {
+			return *newColourTable(options)
+		}
+	}
+	if boldAsBright(options, true) {
+		return result
+	}
+	result.colourMap = map[string]map[string]*Col{
+		"Regular": ColourMap["Regular"],
+		"Bold":    ColourMap["Regular"],
+		"Faint":   ColourMap["Faint"],
+	}
+	return result
+}
+
+// newColourTable returns a colour table that owns copies of the
+// colours selected by the options, so that it is not affected by
+// changes to Cols and ColourMap
+func newColourTable(options []ParseOption) *colourTable {
+	var palette []*Col
+	for _, option := range options {
+		if option.palette != nil {
+			palette = option.palette
+		}
+	}
+
+	// Copy the colours, keeping track of the copy of each one
+	copies := make(map[*Col]*Col)
+	cols := make([]*Col, len(Cols))
+	for index, col := range Cols {
+		if index < len(palette) {
+			col = palette[index]
+		}
+		copied := *col
+		copied.Id = index
+		cols[index] = &copied
+		copies[col] = &copied
+	}
+
+	result := &colourTable{
+		cols:      cols,
+		colourMap: make(map[string]map[string]*Col),
+	}
+	if palette == nil {
+		for name, colourMap := range ColourMap {
+			owned := make(map[string]*Col, len(colourMap))
+			for code, col := range colourMap {
+				copied, ok := copies[col]
+				if !ok {
+					copied = &Col{}
+					*copied = *col
+					copies[col] = copied
+				}
+				owned[code] = copied
+			}
+			result.colourMap[name] = owned
+		}
+	} else {
+		// Build the colour maps in the same layout as ColourMap
+		regular := make(map[string]*Col)
+		bold := make(map[string]*Col)
+		faint := make(map[string]*Col)
+		for index := 0; index < 8; index++ {
+			code := string(rune('0' + index))
+			regular["3"+code] = cols[index]
+			bold["3"+code] = cols[index+8]
+			faint["3"+code] = cols[index]
+			for _, colourMap := range []map[string]*Col{regular, bold, faint} {
+				colourMap["9"+code] = cols[index+8]
+				colourMap["10"+code] = cols[index+8]
+			}
+		}
+		result.colourMap["Regular"] = regular
+		result.colourMap["Bold"] = bold
+		result.colourMap["Faint"] = faint
+	}
+	if !boldAsBright(options, true) {
+		result.colourMap["Bold"] = result.colourMap["Regular"]
+	}
+	return result
+}
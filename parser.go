@@ -0,0 +1,121 @@
+package ansi
+
+import (
+	"strings"
+	"sync"
+)
+
+// Parser parses ANSI encoded strings, carrying the style from the end
+// of one call to Parse into the next. This allows input to be parsed
+// one line at a time when styles span several lines.
+//
+// A Parser created with NewParser owns a copy of its palette, so it
+// is not affected by changes to Cols and ColourMap or by other
+// Parsers. The colours in parsed StyledText point into this palette
+// and should not be modified.
+// The zero value is a Parser with no options and the default style,
+// which uses the package level Cols and ColourMap, as the package
+// level functions do.
+// A Parser is safe for concurrent use.
+type Parser struct {
+	mu     sync.Mutex
+	config *parseConfig
+	state  StyledText
+}
+
+// NewParser returns a new Parser with the default style.
+// The options are applied in the same way as they are for Parse.
+// The palette is copied from the package level Cols and ColourMap,
+// or from WithPalette if it is given.
+func NewParser(options ...ParseOption) *Parser {
+	owned := make([]ParseOption, len(options), len(options)+1)
+	copy(owned, options)
+	owned = append(owned, ParseOption{colours: newColourTable(options)})
+	config := newParseConfig(owned)
+	return &Parser{
+		config: &config,
+	}
+}
+
+// settings returns the settings of the parser. The zero value
+// Parser reads the package level colours each time.
+func (p *Parser) settings() *parseConfig {
+	if p.config == nil {
+		config := newParseConfig(nil)
+		return &config
+	}
+	return p.config
+}
+
+// Palette returns the colours for the 256 colour indexes used
+// by the parser. The colours should not be modified.
+func (p *Parser) Palette() []*Col {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := make([]*Col, 0, len(Cols))
+	return append(result, p.settings().colours.cols...)
+}
+
+// Parse converts an ansi encoded string to a slice of StyledText,
+// starting with the style left by the previous call. The Offset of
+// each StyledText is relative to the start of input.
+// If parsing is unsuccessful, a *ParseError is returned and the style
+// is left unchanged.
+func (p *Parser) Parse(input string) ([]*StyledText, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return parse(input, &p.state, p.settings())
+}
+
+// State returns a copy of the current style. The Label, Offset
+// and Len of the result are not set.
+func (p *Parser) State() *StyledText {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := p.state
+	return &result
+}
+
+// SetState sets the current style. The Label, Offset and Len
+// of state are ignored. A nil state resets the style.
+func (p *Parser) SetState(state *StyledText) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state = StyledText{}
+	if state != nil {
+		p.state = *state
+		p.state.Label = ""
+		p.state.Offset = 0
+		p.state.Len = 0
+	}
+}
+
+// Reset returns the parser to the default style
+func (p *Parser) Reset() {
+	p.SetState(nil)
+}
+
+// MarshalText encodes the current style as the escape sequences
+// that select it, eg. "\033[0;1;31m". The sequences start with a
+// reset, so they can be applied to any existing style.
+func (p *Parser) MarshalText() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	params := append([]string{"0"}, p.state.styleToParams(false)...)
+	result := p.state.linkStart() + "\033[" + strings.Join(params, ";") + "m"
+	return []byte(result), nil
+}
+
+// UnmarshalText sets the current style to the one selected by text,
+// as encoded by MarshalText. Any text outside escape sequences is
+// ignored.
+func (p *Parser) UnmarshalText(text []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var state StyledText
+	if _, err := parse(string(text), &state, p.settings()); err != nil {
+		return err
+	}
+	p.state = state
+	return nil
+}
@@ -0,0 +1,236 @@
+package ansi
+
+import (
+	"sync"
+	"testing"
+
+	is "github.com/matryer/is"
+)
+
+func TestParserKeepsState(t *testing.T) {
+	is2 := is.New(t)
+	p := NewParser()
+	lines := []string{
+		"\033[1;38;5;208mOrange",
+		"still orange",
+		"\033]8;;http://a\033\\link\033[0m plain",
+		"still a link\033]8;;\033\\",
+	}
+	var got []*StyledText
+	for _, line := range lines {
+		parsed, err := p.Parse(line)
+		is2.NoErr(err)
+		got = append(got, parsed...)
+	}
+	is2.Equal(len(got), 5)
+	is2.Equal(got[0].Label, "Orange")
+	is2.Equal(got[1].Label, "still orange")
+	is2.Equal(got[1].FgCol, Cols[208])
+	is2.Equal(got[1].FgMode, TwoFiveSix)
+	is2.True(got[1].Bold())
+	is2.Equal(got[1].Offset, 0)
+	is2.Equal(got[1].Len, 12)
+	is2.Equal(got[2].Label, "link")
+	is2.Equal(got[2].FgCol, Cols[208])
+	is2.Equal(got[2].Hyperlink.URL, "http://a")
+	is2.Equal(got[3].Label, " plain")
+	is2.True(got[3].FgCol == nil)
+	is2.Equal(got[3].Hyperlink.URL, "http://a")
+	is2.Equal(got[4].Label, "still a link")
+	is2.Equal(got[4].Hyperlink.URL, "http://a")
+	is2.Equal(*p.State(), StyledText{})
+}
+
+func TestParserErrorKeepsState(t *testing.T) {
+	is2 := is.New(t)
+	p := NewParser()
+	_, err := p.Parse("\033[31mRed")
+	is2.NoErr(err)
+	_, err = p.Parse("\033[1mBold\033[38;5;300m")
+	is2.True(err != nil)
+	got, err := p.Parse("Red")
+	is2.NoErr(err)
+	is2.Equal(*got[0], StyledText{Label: "Red", FgCol: Cols[1], Len: 3})
+}
+
+func TestParserOptions(t *testing.T) {
+	is2 := is.New(t)
+	p := NewParser(WithIgnoreInvalidCodes())
+	_, err := p.Parse("\033[99;31mRed")
+	is2.NoErr(err)
+	is2.Equal(p.State().FgCol, Cols[1])
+}
+
+func TestParserState(t *testing.T) {
+	is2 := is.New(t)
+	var p Parser
+	p.SetState(&StyledText{Label: "ignored", FgCol: Cols[2], Style: Italic, Offset: 4, Len: 5})
+	is2.Equal(*p.State(), StyledText{FgCol: Cols[2], Style: Italic})
+	got, err := p.Parse("Green")
+	is2.NoErr(err)
+	is2.Equal(*got[0], StyledText{Label: "Green", FgCol: Cols[2], Style: Italic, Len: 5})
+
+	// The state is a copy
+	p.State().Style = Bold
+	is2.Equal(p.State().Style, Italic)
+
+	p.Reset()
+	is2.Equal(*p.State(), StyledText{})
+}
+
+func TestParserMarshalText(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Default", "Plain", "\033[0m"},
+		{"Styles", "\033[3;4;31mA", "\033[0;3;4;31m"},
+		{"256 colours", "\033[38;5;208;48;5;18mA", "\033[0;38;5;208;48;5;18m"},
+		{"True colour", "\033[38;2;1;2;3mA", "\033[0;38;2;1;2;3m"},
+		{"Hyperlink", "\033]8;id=x;http://a\033\\\033[32mA", "\033]8;id=x;http://a\033\\\033[0;32m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			_, err := p.Parse(tt.input)
+			is2.NoErr(err)
+			text, err := p.MarshalText()
+			is2.NoErr(err)
+			is2.Equal(string(text), tt.want)
+
+			resumed := NewParser()
+			is2.NoErr(resumed.UnmarshalText(text))
+			is2.Equal(*resumed.State(), *p.State())
+		})
+	}
+}
+
+func TestParserUnmarshalTextError(t *testing.T) {
+	is2 := is.New(t)
+	p := NewParser()
+	p.SetState(&StyledText{Style: Bold})
+	is2.True(p.UnmarshalText([]byte("\033[38;5;300m")) != nil)
+	is2.Equal(p.State().Style, Bold)
+}
+
+func TestParserConcurrent(t *testing.T) {
+	is2 := is.New(t)
+	p := NewParser()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, err := p.Parse("\033[31mRed\033[0m")
+				is2.NoErr(err)
+				_ = p.State()
+			}
+		}()
+	}
+	wg.Wait()
+	is2.Equal(*p.State(), StyledText{})
+}
+
+func TestParserOwnsPalette(t *testing.T) {
+	is2 := is.New(t)
+	p := NewParser()
+
+	// Changes to the package level colours do not affect the parser
+	original := ColourMap["Regular"]["31"]
+	ColourMap["Regular"]["31"] = &Col{Id: 1, Name: "Changed"}
+	defer func() { ColourMap["Regular"]["31"] = original }()
+	got, err := p.Parse("\033[31mA\033[38;5;208mB")
+	is2.NoErr(err)
+	is2.Equal(got[0].FgCol.Name, "Maroon")
+	is2.Equal(got[1].FgCol.Name, "DarkOrange")
+
+	// Changes to returned colours do not affect the package level colours
+	is2.True(got[1].FgCol != Cols[208])
+	got[1].FgCol.Name = "Corrupted"
+	is2.Equal(Cols[208].Name, "DarkOrange")
+
+	// The package level functions still use the package level colours
+	got, err = Parse("\033[31mA")
+	is2.NoErr(err)
+	is2.Equal(got[0].FgCol.Name, "Changed")
+
+	// A new parser copies colours that are only in the colour map
+	got, err = NewParser().Parse("\033[31mA")
+	is2.NoErr(err)
+	is2.Equal(got[0].FgCol.Name, "Changed")
+	is2.True(got[0].FgCol != ColourMap["Regular"]["31"])
+}
+
+func TestParserPalette(t *testing.T) {
+	is2 := is.New(t)
+	palette := make([]*Col, 16)
+	for index := range palette {
+		palette[index] = &Col{Name: "Custom" + string(rune('A'+index))}
+	}
+	p := NewParser(WithPalette(palette))
+	palette[1].Name = "Changed after"
+
+	got, err := p.Parse("\033[31mA\033[1mB\033[22;91mC\033[41mD\033[38;5;1mE\033[38;5;208mF")
+	is2.NoErr(err)
+	is2.Equal(got[0].FgCol.Name, "CustomB")
+	is2.Equal(got[0].FgCol.Id, 1)
+	is2.Equal(got[1].FgCol.Name, "CustomB")
+	is2.Equal(got[2].FgCol.Name, "CustomJ")
+	is2.Equal(got[3].BgCol.Name, "CustomB")
+	is2.Equal(got[4].FgCol.Name, "CustomB")
+	is2.Equal(got[5].FgCol.Name, "DarkOrange")
+
+	// Bold as bright applies to the next sequence's colours
+	got, err = p.Parse("\033[0;1;32mA")
+	is2.NoErr(err)
+	is2.Equal(got[0].FgCol.Name, "CustomK")
+
+	is2.Equal(len(p.Palette()), 256)
+	is2.Equal(p.Palette()[2].Name, "CustomC")
+
+	// The package level functions accept a palette too
+	got, err = Parse("\033[33mA", WithPalette(palette))
+	is2.NoErr(err)
+	is2.Equal(got[0].FgCol.Name, "CustomD")
+}
+
+func TestBoldAsBright(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name    string
+		options []ParseOption
+		want    *Col
+	}{
+		{"Default", nil, Cols[9]},
+		{"Enabled", []ParseOption{WithBoldAsBright(true)}, Cols[9]},
+		{"Disabled", []ParseOption{WithBoldAsBright(false)}, Cols[1]},
+		{"Last wins", []ParseOption{WithBoldAsBright(false), WithBoldAsBright(true)}, Cols[9]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse("\033[1;31mA", tt.options...)
+			is2.NoErr(err)
+			is2.Equal(got[0].FgCol, tt.want)
+
+			got, err = NewParser(tt.options...).Parse("\033[1;31mA")
+			is2.NoErr(err)
+			is2.Equal(*got[0].FgCol, *tt.want)
+
+			// Bold set by an earlier call applies to the colour
+			p := NewParser(tt.options...)
+			_, err = p.Parse("\033[1m")
+			is2.NoErr(err)
+			got, err = p.Parse("\033[31mA")
+			is2.NoErr(err)
+			is2.Equal(*got[0].FgCol, *tt.want)
+
+			// Normal intensity goes back to the standard colour
+			got, err = p.Parse("\033[22mB")
+			is2.NoErr(err)
+			is2.Equal(*got[0].FgCol, *Cols[1])
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package ansi
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/rivo/uniseg"
+)
+
+// The sizes used by Render, in pixels. Each cell leaves a pixel
+// between glyphs and a pixel above and below them.
+const (
+	renderCellWidth  = fontWidth + 1
+	renderLineHeight = fontHeight + 2
+	// renderBaseline is the offset from the top of a line of the
+	// lowest row of a glyph that is above the baseline
+	renderBaseline = fontAscent
+	renderPadding  = 8
+	// renderTitleBar is the height of the window title bar
+	renderTitleBar = 24
+	// renderButton is the radius of the window buttons
+	renderButton = 5
+)
+
+// Render draws an ansi encoded string into an image that looks like
+// a screenshot of a terminal, using a built in 6x13 bitmap font, so
+// the result is the same wherever it is run. The text is laid out in
+// the same way as SVG. The font only has the printable ASCII
+// characters, and any other character is drawn as a replacement
+// character, which is centred in both cells of a wide character.
+// Colours, bold, faint, underline, strikethrough, overline, inversed
+// and invisible text are drawn. Bold text is drawn twice, a pixel
+// apart. The image is an *image.RGBA, which can be saved with
+// image/png.
+// If parsing is unsuccessful, a *ParseError is returned.
+func Render(input string, options ...RenderOption) (image.Image, error) {
+	config := newRenderConfig(options)
+	lines, err := layout(input, config.parseOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	width := layoutWidth(lines)*renderCellWidth + 2*renderPadding
+	top := renderPadding
+	if config.window {
+		top += renderTitleBar
+		// Leave room for the buttons either side of the title
+		titleWidth := uniseg.StringWidth(config.title)*renderCellWidth + 2*(renderPadding+len(windowButtons)*3*renderButton)
+		if width < titleWidth {
+			width = titleWidth
+		}
+	}
+	height := top + len(lines)*renderLineHeight + renderPadding
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, img.Bounds(), config.background)
+	if config.window {
+		for i, colour := range windowButtons {
+			fillCircle(img, renderPadding+renderButton+i*3*renderButton, renderTitleBar/2, renderButton, colour)
+		}
+		if config.title != "" {
+			var title []layoutCell
+			titleColumns := 0
+			for state, rest := -1, config.title; rest != ""; {
+				var cluster string
+				var columns int
+				cluster, rest, columns, state = uniseg.FirstGraphemeClusterInString(rest, state)
+				title = append(title, layoutCell{char: cluster, width: columns, style: &StyledText{}})
+				titleColumns += columns
+			}
+			config.drawLine(img, title, (width-titleColumns*renderCellWidth)/2, (renderTitleBar-renderLineHeight)/2)
+		}
+	}
+	for row, line := range lines {
+		config.drawLine(img, line, renderPadding, top+row*renderLineHeight)
+	}
+	return img, nil
+}
+
+// drawLine draws the backgrounds, text and lines for a line of
+// cells, with its top left corner at x, y
+func (c *renderConfig) drawLine(img *image.RGBA, line []layoutCell, x, y int) {
+	column := 0
+	for _, cell := range line {
+		left := x + column*renderCellWidth
+		column += cell.width
+		fg, bg := c.cellColours(cell.style)
+		if bg != nil {
+			fillRect(img, image.Rect(left, y, left+cell.width*renderCellWidth, y+renderLineHeight), bg)
+		}
+		if cell.style.Invisible() {
+			continue
+		}
+		// Wide characters are centred in their cells
+		glyphLeft := left + (cell.width-1)*renderCellWidth/2
+		drawGlyph(img, glyph(cell.char), glyphLeft, y+1, fg)
+		if cell.style.Bold() {
+			drawGlyph(img, glyph(cell.char), glyphLeft+1, y+1, fg)
+		}
+		// Underlines use the underline colour, if one is set
+		for _, kind := range textLines {
+			colour, ok := c.lineColour(cell.style, kind)
+			if !ok {
+				continue
+			}
+			for _, offset := range kind.offsets(cell.style, renderBaseline) {
+				fillRect(img, image.Rect(left, y+offset, left+cell.width*renderCellWidth, y+offset+1), colour)
+			}
+		}
+	}
+}
+
+// drawGlyph draws the set pixels of g with its top left corner at x, y
+func drawGlyph(img *image.RGBA, g *[fontHeight]byte, x, y int, col *Col) {
+	colour := colourRGBA(col)
+	for row, bits := range g {
+		for column := 0; column < fontWidth; column++ {
+			if bits&(0x80>>uint(column)) != 0 {
+				img.SetRGBA(x+column, y+row, colour)
+			}
+		}
+	}
+}
+
+// fillRect fills r with col
+func fillRect(img *image.RGBA, r image.Rectangle, col *Col) {
+	draw.Draw(img, r, image.NewUniform(colourRGBA(col)), image.Point{}, draw.Src)
+}
+
+// fillCircle fills the circle with the given centre and radius with col
+func fillCircle(img *image.RGBA, x, y, radius int, col *Col) {
+	colour := colourRGBA(col)
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy <= radius*radius {
+				img.SetRGBA(x+dx, y+dy, colour)
+			}
+		}
+	}
+}
+
+// colourRGBA returns the opaque colour for col
+func colourRGBA(col *Col) color.RGBA {
+	return color.RGBA{R: col.Rgb.R, G: col.Rgb.G, B: col.Rgb.B, A: 0xff}
+}
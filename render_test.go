@@ -0,0 +1,107 @@
+package ansi
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRender(t *testing.T) {
+	is2 := is.New(t)
+	// The '|' glyph is in the fourth column of pixels, from the third row
+	// to the eleventh, so the pixel to its right is only set for bold
+	tests := []struct {
+		name   string
+		input  string
+		pixels map[image.Point]*Col
+	}{
+		{"Background", "|", map[image.Point]*Col{{0, 0}: Cols[0], {14, 8}: Cols[0]}},
+		{"Foreground", "\033[31m|", map[image.Point]*Col{{11, 12}: Cols[1], {12, 12}: Cols[0]}},
+		{"Bold", "\033[1;31m|", map[image.Point]*Col{{11, 12}: Cols[9], {12, 12}: Cols[9]}},
+		{"Cell background", "\033[42m|", map[image.Point]*Col{{11, 12}: Cols[7], {14, 8}: Cols[2], {8, 22}: Cols[2], {15, 8}: Cols[0]}},
+		{"Inversed", "\033[7m|", map[image.Point]*Col{{11, 12}: Cols[0], {14, 8}: Cols[7]}},
+		{"Inversed colours", "\033[7;31;42m|", map[image.Point]*Col{{11, 12}: Cols[2], {14, 8}: Cols[1]}},
+		{"Invisible", "\033[8;42m|", map[image.Point]*Col{{11, 12}: Cols[2]}},
+		{"Underline", "\033[4m ", map[image.Point]*Col{{8, 21}: Cols[7], {14, 21}: Cols[7], {8, 20}: Cols[0]}},
+		{"Double underline", "\033[21m ", map[image.Point]*Col{{8, 20}: Cols[7], {8, 21}: Cols[0], {8, 22}: Cols[7]}},
+		{"Underline colour", "\033[4;58;5;1m ", map[image.Point]*Col{{8, 21}: Cols[1]}},
+		{"Strikethrough", "\033[9m ", map[image.Point]*Col{{8, 14}: Cols[7], {8, 13}: Cols[0]}},
+		{"Overline", "\033[53m ", map[image.Point]*Col{{8, 9}: Cols[7]}},
+		{"Second line", "\n\033[31m|", map[image.Point]*Col{{11, 12}: Cols[0], {11, 27}: Cols[1]}},
+		{"Wide character", "\033[41m😀|", map[image.Point]*Col{{21, 8}: Cols[1], {25, 12}: Cols[7]}},
+		{"Tab", "\t\033[31m|", map[image.Point]*Col{{67, 12}: Cols[1]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.input)
+			is2.NoErr(err)
+			img := got.(*image.RGBA)
+			for point, want := range tt.pixels {
+				is2.Equal(img.RGBAAt(point.X, point.Y), colourRGBA(want))
+			}
+		})
+	}
+}
+
+func TestRenderSize(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name    string
+		input   string
+		options []RenderOption
+		want    image.Rectangle
+	}{
+		{"Blank", "", nil, image.Rect(0, 0, 16, 31)},
+		{"Line", "Hello", nil, image.Rect(0, 0, 51, 31)},
+		{"Lines", "Hi\nHello\n", nil, image.Rect(0, 0, 51, 46)},
+		{"Wide", "😀", nil, image.Rect(0, 0, 30, 31)},
+		{"Window", "Hello", []RenderOption{WithWindow("")}, image.Rect(0, 0, 106, 55)},
+		{"Window title", "Hi", []RenderOption{WithWindow("Title")}, image.Rect(0, 0, 141, 55)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.input, tt.options...)
+			is2.NoErr(err)
+			is2.Equal(got.Bounds(), tt.want)
+		})
+	}
+}
+
+func TestRenderOptions(t *testing.T) {
+	is2 := is.New(t)
+	foreground := &Col{Rgb: Rgb{R: 1, G: 2, B: 3}}
+	background := &Col{Rgb: Rgb{R: 4, G: 5, B: 6}}
+	got, err := Render("|\033[7m|", WithTerminalColours(foreground, background), WithWindow("T"))
+	is2.NoErr(err)
+	img := got.(*image.RGBA)
+	is2.Equal(img.RGBAAt(0, 0), colourRGBA(background))
+	is2.Equal(img.RGBAAt(11, 36), colourRGBA(foreground))
+	is2.Equal(img.RGBAAt(18, 36), colourRGBA(background))
+	is2.Equal(img.RGBAAt(21, 32), colourRGBA(foreground))
+	is2.Equal(img.RGBAAt(13, 12), colourRGBA(windowButtons[0]))
+
+	// The palette is used for the default colours and the 256 colours
+	palette := make([]*Col, 256)
+	for i := range palette {
+		palette[i] = &Col{Id: i, Rgb: Rgb{R: uint8(i)}}
+	}
+	got, err = Render("\033[38;5;100m|", WithRenderPalette(palette))
+	is2.NoErr(err)
+	img = got.(*image.RGBA)
+	is2.Equal(img.RGBAAt(0, 0), colourRGBA(palette[0]))
+	is2.Equal(img.RGBAAt(11, 12), colourRGBA(palette[100]))
+
+	// The image can be saved as a PNG
+	var buffer bytes.Buffer
+	is2.NoErr(png.Encode(&buffer, got))
+
+	// Parse errors are returned
+	_, err = Render("\033[38;5;300mText")
+	is2.True(errors.Is(err, ErrInvalid256ColSequence))
+	_, err = Render("\033[99mText", WithRenderParseOptions(WithIgnoreInvalidCodes()))
+	is2.NoErr(err)
+}
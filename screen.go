@@ -0,0 +1,671 @@
+package ansi
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// tabWidth is the distance between tab stops
+const tabWidth = 8
+
+// Cell is a single character cell of a Screen
+type Cell struct {
+	// Char is the grapheme cluster displayed in the cell.
+	// It is empty for blank cells.
+	Char string
+	// Style is the style of the cell, or nil for the default style.
+	// The Label of the style is not used.
+	Style *StyledText
+	// wide is true for the cell holding the right half of a wide
+	// character
+	wide bool
+}
+
+// screenCursor is the cursor state saved by DECSC
+type screenCursor struct {
+	x, y int
+	pen  StyledText
+}
+
+// Screen is a virtual terminal screen. Output written to the screen
+// is parsed and applied to a grid of character cells, in the same way
+// a terminal would, so that the final frame can be read back.
+// Line feeds also return the cursor to the start of the line, as
+// terminals do when output post-processing is enabled.
+type Screen struct {
+	width, height int
+	config        parseConfig
+
+	main, alternate [][]Cell
+	cells           [][]Cell
+
+	x, y int
+	// wrapPending is set when a character has been written to the
+	// last column, so the next character wraps to the next line
+	wrapPending bool
+	autoWrap    bool
+	// top and bottom are the rows of the scroll region
+	top, bottom int
+
+	pen      StyledText
+	penStyle *StyledText
+	saved    screenCursor
+	// savedMain is the cursor saved when switching to the alternate screen
+	savedMain screenCursor
+
+	// buf holds an incomplete sequence or rune from the last write
+	buf string
+}
+
+// NewScreen returns a new blank Screen with the given size.
+// The options are applied when parsing SGR sequences, in the
+// same way as they are for Parse.
+func NewScreen(width, height int, options ...ParseOption) *Screen {
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	result := &Screen{
+		width:  width,
+		height: height,
+		config: newParseConfig(options),
+	}
+	result.reset()
+	return result
+}
+
+// reset returns the screen to its initial state
+func (s *Screen) reset() {
+	s.main = newCells(s.width, s.height)
+	s.alternate = nil
+	s.cells = s.main
+	s.x, s.y = 0, 0
+	s.wrapPending = false
+	s.autoWrap = true
+	s.top, s.bottom = 0, s.height-1
+	s.pen = StyledText{}
+	s.penStyle = nil
+	s.saved = screenCursor{}
+	s.savedMain = screenCursor{}
+}
+
+// newCells returns a blank grid of cells
+func newCells(width, height int) [][]Cell {
+	result := make([][]Cell, height)
+	for y := range result {
+		result[y] = make([]Cell, width)
+	}
+	return result
+}
+
+// Width returns the width of the screen in cells
+func (s *Screen) Width() int {
+	return s.width
+}
+
+// Height returns the height of the screen in cells
+func (s *Screen) Height() int {
+	return s.height
+}
+
+// Cursor returns the position of the cursor
+func (s *Screen) Cursor() (x, y int) {
+	return s.x, s.y
+}
+
+// Cell returns the cell at the given position
+func (s *Screen) Cell(x, y int) Cell {
+	return s.cells[y][x]
+}
+
+// WriteString is like Write, but writes the contents of str
+func (s *Screen) WriteString(str string) (int, error) {
+	return s.Write([]byte(str))
+}
+
+// Write applies p to the screen. Sequences and runes may be split
+// across calls to Write. Malformed sequences are ignored, as they
+// would be by a terminal, so Write never returns an error.
+func (s *Screen) Write(p []byte) (int, error) {
+	input := s.buf + string(p)
+	s.buf = ""
+	index := 0
+	for index < len(input) {
+		b := input[index]
+		switch {
+		case b == '\033':
+			var token Token
+			length, err := scanToken(input[index:], &token)
+			if err == ErrMissingTerminator {
+				s.buf = input[index:]
+				return len(p), nil
+			}
+			if err != nil {
+				index++
+				continue
+			}
+			s.sequence(&token, input[index:index+length])
+			index += length
+		case b < 0x20 || b == 0x7f:
+			s.control(b)
+			index++
+		default:
+			end := index + 1
+			for end < len(input) && input[end] >= 0x20 && input[end] != 0x7f {
+				end++
+			}
+			text := input[index:end]
+			if end == len(input) {
+				text = trimPartialRune(text)
+				s.buf = input[index+len(text):]
+			}
+			s.print(text)
+			index = end
+		}
+	}
+	return len(p), nil
+}
+
+// print writes text at the cursor
+func (s *Screen) print(text string) {
+	state := -1
+	var cluster string
+	var width int
+	for len(text) > 0 {
+		cluster, text, width, state = uniseg.FirstGraphemeClusterInString(text, state)
+		if width == 0 {
+			continue
+		}
+		if width > s.width {
+			width = s.width
+		}
+		if s.wrapPending || s.x+width > s.width {
+			if s.autoWrap {
+				s.x = 0
+				s.lineFeed()
+			} else {
+				s.x = s.width - width
+			}
+		}
+		s.wrapPending = false
+		s.setCell(s.x, s.y, Cell{Char: cluster, Style: s.penStyle})
+		if width == 2 {
+			s.setCell(s.x+1, s.y, Cell{Style: s.penStyle, wide: true})
+		}
+		s.x += width
+		if s.x >= s.width {
+			s.x = s.width - 1
+			s.wrapPending = true
+		}
+	}
+}
+
+// setCell sets a cell, clearing the other half of any wide
+// character that is overwritten
+func (s *Screen) setCell(x, y int, cell Cell) {
+	row := s.cells[y]
+	if row[x].wide && x > 0 {
+		row[x-1] = Cell{Style: row[x-1].Style}
+	}
+	if x+1 < s.width && row[x+1].wide && !cell.wide {
+		row[x+1] = Cell{Style: row[x+1].Style}
+	}
+	row[x] = cell
+}
+
+// control applies a C0 control character
+func (s *Screen) control(b byte) {
+	switch b {
+	case '\r':
+		s.x = 0
+		s.wrapPending = false
+	case '\n', '\v', '\f':
+		s.x = 0
+		s.wrapPending = false
+		s.lineFeed()
+	case '\b':
+		if s.x > 0 {
+			s.x--
+		}
+		s.wrapPending = false
+	case '\t':
+		s.x = (s.x/tabWidth + 1) * tabWidth
+		if s.x >= s.width {
+			s.x = s.width - 1
+		}
+	}
+}
+
+// sequence applies an escape sequence
+func (s *Screen) sequence(token *Token, raw string) {
+	switch token.Type {
+	case SGRToken, OSCToken:
+		// Invalid parameters are ignored
+		_, _ = s.pen.applySequence(raw, &s.config)
+		s.penStyle = nil
+		if s.pen != (StyledText{}) {
+			pen := s.pen
+			s.penStyle = &pen
+		}
+	case CSIToken:
+		s.csi(token)
+	case ESCToken:
+		if len(raw) == 2 {
+			s.escape(token.Final)
+		}
+	}
+}
+
+// escape applies an escape sequence with the given final byte
+func (s *Screen) escape(final byte) {
+	switch final {
+	case '7':
+		s.saveCursor()
+	case '8':
+		s.restoreCursor()
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.x = 0
+		s.lineFeed()
+	case 'M':
+		s.reverseLineFeed()
+	case 'c':
+		s.reset()
+	}
+}
+
+// csi applies a control sequence
+func (s *Screen) csi(token *Token) {
+	if token.Intermediates != "" {
+		return
+	}
+	if strings.HasPrefix(token.Params, "?") {
+		s.privateMode(token)
+		return
+	}
+	params := csiParams(token.Params)
+	n := param(params, 0, 1)
+	switch token.Final {
+	case 'A':
+		s.moveTo(s.x, s.y-n)
+	case 'B', 'e':
+		s.moveTo(s.x, s.y+n)
+	case 'C', 'a':
+		s.moveTo(s.x+n, s.y)
+	case 'D':
+		s.moveTo(s.x-n, s.y)
+	case 'E':
+		s.moveTo(0, s.y+n)
+	case 'F':
+		s.moveTo(0, s.y-n)
+	case 'G', '`':
+		s.moveTo(n-1, s.y)
+	case 'd':
+		s.moveTo(s.x, n-1)
+	case 'H', 'f':
+		s.moveTo(param(params, 1, 1)-1, n-1)
+	case 'J':
+		s.eraseDisplay(param(params, 0, 0))
+	case 'K':
+		s.eraseLine(param(params, 0, 0))
+	case 'L':
+		s.insertLines(n)
+	case 'M':
+		s.deleteLines(n)
+	case '@':
+		s.insertChars(n)
+	case 'P':
+		s.deleteChars(n)
+	case 'X':
+		s.eraseChars(s.x, s.x+n)
+	case 'S':
+		s.scrollUp(s.top, s.bottom, n)
+	case 'T':
+		s.scrollDown(s.top, s.bottom, n)
+	case 'r':
+		top := param(params, 0, 1) - 1
+		bottom := param(params, 1, s.height) - 1
+		if bottom >= s.height {
+			bottom = s.height - 1
+		}
+		if top < bottom {
+			s.top, s.bottom = top, bottom
+			s.moveTo(0, 0)
+		}
+	case 's':
+		s.saveCursor()
+	case 'u':
+		s.restoreCursor()
+	}
+}
+
+// privateMode applies a DEC private mode sequence
+func (s *Screen) privateMode(token *Token) {
+	if token.Final != 'h' && token.Final != 'l' {
+		return
+	}
+	set := token.Final == 'h'
+	for _, mode := range csiParams(token.Params[1:]) {
+		switch mode {
+		case 7:
+			s.autoWrap = set
+		case 47, 1047:
+			s.useAlternate(set)
+		case 1049:
+			if set {
+				s.saveCursor()
+				s.savedMain = s.saved
+				s.useAlternate(true)
+				continue
+			}
+			s.useAlternate(false)
+			s.saved = s.savedMain
+			s.restoreCursor()
+		}
+	}
+}
+
+// useAlternate switches to or from the alternate screen. The
+// alternate screen is cleared each time it is used.
+func (s *Screen) useAlternate(alternate bool) {
+	if alternate {
+		s.alternate = newCells(s.width, s.height)
+		s.cells = s.alternate
+		return
+	}
+	s.cells = s.main
+	s.alternate = nil
+}
+
+// saveCursor saves the cursor position and style
+func (s *Screen) saveCursor() {
+	s.saved = screenCursor{x: s.x, y: s.y, pen: s.pen}
+}
+
+// restoreCursor restores the cursor position and style
+func (s *Screen) restoreCursor() {
+	s.moveTo(s.saved.x, s.saved.y)
+	s.pen = s.saved.pen
+	s.penStyle = nil
+	if s.pen != (StyledText{}) {
+		pen := s.pen
+		s.penStyle = &pen
+	}
+}
+
+// moveTo moves the cursor, keeping it on the screen
+func (s *Screen) moveTo(x, y int) {
+	if x < 0 {
+		x = 0
+	}
+	if x >= s.width {
+		x = s.width - 1
+	}
+	if y < 0 {
+		y = 0
+	}
+	if y >= s.height {
+		y = s.height - 1
+	}
+	s.x, s.y = x, y
+	s.wrapPending = false
+}
+
+// lineFeed moves the cursor down a line, scrolling the
+// scroll region if the cursor is on its bottom line
+func (s *Screen) lineFeed() {
+	if s.y == s.bottom {
+		s.scrollUp(s.top, s.bottom, 1)
+		return
+	}
+	if s.y < s.height-1 {
+		s.y++
+	}
+}
+
+// reverseLineFeed moves the cursor up a line, scrolling the
+// scroll region if the cursor is on its top line
+func (s *Screen) reverseLineFeed() {
+	if s.y == s.top {
+		s.scrollDown(s.top, s.bottom, 1)
+		return
+	}
+	if s.y > 0 {
+		s.y--
+	}
+}
+
+// blank returns an erased cell, which keeps the current background colour
+func (s *Screen) blank() Cell {
+	if s.pen.BgCol == nil {
+		return Cell{}
+	}
+	return Cell{Style: &StyledText{BgCol: s.pen.BgCol, BgMode: s.pen.BgMode}}
+}
+
+// blankRow returns an erased row
+func (s *Screen) blankRow() []Cell {
+	row := make([]Cell, s.width)
+	blank := s.blank()
+	for x := range row {
+		row[x] = blank
+	}
+	return row
+}
+
+// scrollUp scrolls the lines from top to bottom up by n lines
+func (s *Screen) scrollUp(top, bottom, n int) {
+	if n > bottom-top+1 {
+		n = bottom - top + 1
+	}
+	for ; n > 0; n-- {
+		copy(s.cells[top:bottom], s.cells[top+1:bottom+1])
+		s.cells[bottom] = s.blankRow()
+	}
+}
+
+// scrollDown scrolls the lines from top to bottom down by n lines
+func (s *Screen) scrollDown(top, bottom, n int) {
+	if n > bottom-top+1 {
+		n = bottom - top + 1
+	}
+	for ; n > 0; n-- {
+		copy(s.cells[top+1:bottom+1], s.cells[top:bottom])
+		s.cells[top] = s.blankRow()
+	}
+}
+
+// insertLines inserts n blank lines at the cursor
+func (s *Screen) insertLines(n int) {
+	if s.y < s.top || s.y > s.bottom {
+		return
+	}
+	s.scrollDown(s.y, s.bottom, n)
+	s.x = 0
+}
+
+// deleteLines deletes n lines at the cursor
+func (s *Screen) deleteLines(n int) {
+	if s.y < s.top || s.y > s.bottom {
+		return
+	}
+	s.scrollUp(s.y, s.bottom, n)
+	s.x = 0
+}
+
+// insertChars inserts n blank characters at the cursor
+func (s *Screen) insertChars(n int) {
+	row := s.cells[s.y]
+	if n > s.width-s.x {
+		n = s.width - s.x
+	}
+	copy(row[s.x+n:], row[s.x:])
+	s.eraseChars(s.x, s.x+n)
+}
+
+// deleteChars deletes n characters at the cursor
+func (s *Screen) deleteChars(n int) {
+	row := s.cells[s.y]
+	if n > s.width-s.x {
+		n = s.width - s.x
+	}
+	copy(row[s.x:], row[s.x+n:])
+	s.eraseChars(s.width-n, s.width)
+}
+
+// eraseChars erases the characters from start up to end
+// on the cursor line
+func (s *Screen) eraseChars(start, end int) {
+	if end > s.width {
+		end = s.width
+	}
+	blank := s.blank()
+	for x := start; x < end; x++ {
+		s.setCell(x, s.y, blank)
+	}
+	s.wrapPending = false
+}
+
+// eraseLine erases part of the cursor line: 0 to the end,
+// 1 to the start, 2 the whole line
+func (s *Screen) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		s.eraseChars(s.x, s.width)
+	case 1:
+		s.eraseChars(0, s.x+1)
+	case 2:
+		s.eraseChars(0, s.width)
+	}
+}
+
+// eraseDisplay erases part of the screen: 0 to the end,
+// 1 to the start, 2 and 3 the whole screen
+func (s *Screen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.eraseLine(0)
+		for y := s.y + 1; y < s.height; y++ {
+			s.cells[y] = s.blankRow()
+		}
+	case 1:
+		s.eraseLine(1)
+		for y := 0; y < s.y; y++ {
+			s.cells[y] = s.blankRow()
+		}
+	case 2, 3:
+		for y := range s.cells {
+			s.cells[y] = s.blankRow()
+		}
+	}
+}
+
+// Lines returns the text on the screen as one slice of StyledText
+// per line. Blank cells at the end of each line are left out.
+// The Offset and Len of each StyledText are not set.
+func (s *Screen) Lines() [][]*StyledText {
+	result := make([][]*StyledText, s.height)
+	for y, row := range s.cells {
+		end := len(row)
+		for end > 0 && row[end-1].Char == "" && row[end-1].Style == nil {
+			end--
+		}
+		var line []*StyledText
+		var label strings.Builder
+		var style *StyledText
+		flush := func() {
+			if label.Len() == 0 {
+				return
+			}
+			text := &StyledText{}
+			if style != nil {
+				*text = *style
+			}
+			text.Label = label.String()
+			line = append(line, text)
+			label.Reset()
+		}
+		for _, cell := range row[:end] {
+			if cell.wide {
+				continue
+			}
+			if !sameStyle(cell.Style, style) {
+				flush()
+				style = cell.Style
+			}
+			if cell.Char == "" {
+				label.WriteByte(' ')
+				continue
+			}
+			label.WriteString(cell.Char)
+		}
+		flush()
+		result[y] = line
+	}
+	return result
+}
+
+// String returns the text on the screen without styles. Spaces at
+// the end of each line and blank lines at the end of the screen
+// are left out.
+func (s *Screen) String() string {
+	lines := make([]string, s.height)
+	for y, row := range s.cells {
+		var line strings.Builder
+		for _, cell := range row {
+			if cell.wide {
+				continue
+			}
+			if cell.Char == "" {
+				line.WriteByte(' ')
+				continue
+			}
+			line.WriteString(cell.Char)
+		}
+		lines[y] = strings.TrimRight(line.String(), " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sameStyle returns true if a and b have the same style
+func sameStyle(a, b *StyledText) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	x, y := *a, *b
+	x.Label, y.Label = "", ""
+	return x == y
+}
+
+// csiParams returns the numeric parameters of a control sequence.
+// Missing parameters are returned as -1.
+func csiParams(params string) []int {
+	if params == "" {
+		return nil
+	}
+	var result []int
+	for _, param := range strings.Split(params, ";") {
+		value, err := strconv.Atoi(param)
+		if err != nil {
+			value = -1
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
+// param returns the parameter at index, or def if it is missing or 0
+func param(params []int, index, def int) int {
+	if index >= len(params) || params[index] <= 0 {
+		return def
+	}
+	return params[index]
+}
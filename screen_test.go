@@ -0,0 +1,184 @@
+package ansi
+
+import (
+	"testing"
+
+	is "github.com/matryer/is"
+)
+
+func TestScreenString(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name          string
+		width, height int
+		input         string
+		want          string
+	}{
+		{"Blank", 10, 3, "", ""},
+		{"Text", 10, 3, "Hello", "Hello"},
+		{"Newlines", 10, 3, "one\ntwo\r\nthree", "one\ntwo\nthree"},
+		{"Carriage return", 10, 3, "Hello\rJ", "Jello"},
+		{"Backspace", 10, 3, "Hellp\bo", "Hello"},
+		{"Tab", 20, 3, "a\tb", "a       b"},
+		{"Wrap", 5, 3, "HelloWorld", "Hello\nWorld"},
+		{"Wrap pending", 5, 3, "Hello\r\nWorld", "Hello\nWorld"},
+		{"No autowrap", 5, 3, "\033[?7lHelloWorld", "Helld"},
+		{"Scroll", 5, 2, "one\ntwo\nthree", "two\nthree"},
+		{"Cursor position", 10, 3, "\033[2;3HX\033[1;1HY", "Y\n  X"},
+		{"Cursor movement", 10, 3, "\033[2B\033[4CX\033[AY\033[3DZ", "\n   Z Y\n    X"},
+		{"Column and row", 10, 3, "\033[5GX\033[3dY", "    X\n\n     Y"},
+		{"Next and previous line", 10, 3, "ab\033[Ecd\033[Fef", "ef\ncd"},
+		{"Erase line to end", 10, 3, "Hello\033[3D\033[K", "He"},
+		{"Erase line to start", 10, 3, "Hello\033[3D\033[1K", "   lo"},
+		{"Erase line", 10, 3, "Hello\033[2Kab", "     ab"},
+		{"Erase display", 10, 3, "one\ntwo\nthree\033[2J", ""},
+		{"Erase display to end", 10, 3, "one\ntwo\nthree\033[2;2H\033[J", "one\nt"},
+		{"Erase display to start", 10, 3, "one\ntwo\nthree\033[2;2H\033[1J", "\n  o\nthree"},
+		{"Erase characters", 10, 3, "Hello\033[1G\033[2X", "  llo"},
+		{"Insert characters", 10, 3, "Hello\033[1G\033[2@", "  Hello"},
+		{"Delete characters", 10, 3, "Hello\033[1G\033[2P", "llo"},
+		{"Insert lines", 10, 3, "one\ntwo\nthree\033[2H\033[L", "one\n\ntwo"},
+		{"Delete lines", 10, 3, "one\ntwo\nthree\033[1H\033[M", "two\nthree"},
+		{"Scroll up", 10, 3, "one\ntwo\nthree\033[S", "two\nthree"},
+		{"Scroll down", 10, 3, "one\ntwo\nthree\033[T", "\none\ntwo"},
+		{"Scroll region", 10, 4, "head\033[2;3r\033[3Hone\ntwo\nthree\033[4Hfoot", "head\ntwo\nthree\nfoot"},
+		{"Large scroll up", 10, 12, "one\033[5;10r\033[99999999Stwo", "two"},
+		{"Large scroll down", 10, 3, "one\ntwo\033[99999999T", ""},
+		{"Large insert lines", 10, 3, "one\ntwo\033[H\033[99999999L", ""},
+		{"Large delete lines", 10, 3, "one\ntwo\033[2H\033[99999999M", "one"},
+		{"Reverse index", 10, 3, "one\ntwo\033[H\033M", "\none\ntwo"},
+		{"Reverse index below top", 10, 3, "one\ntwo\033Mx", "onex\ntwo"},
+		{"Reverse index in scroll region", 10, 3, "one\033[2;3r\033[2Htwo\033Mx", "one\n   x\ntwo"},
+		{"Index", 10, 3, "one\033Dtwo", "one\n   two"},
+		{"Index scrolls", 10, 2, "one\ntwo\033Dx", "two\n   x"},
+		{"Next line", 10, 3, "one\033Etwo", "one\ntwo"},
+		{"Insert lines below scroll region", 10, 4, "one\ntwo\nthree\nfour\033[2;3r\033[4H\033[Lx", "one\ntwo\nthree\nxour"},
+		{"Delete lines above scroll region", 10, 4, "one\ntwo\nthree\nfour\033[2;3r\033[1H\033[Mx", "xne\ntwo\nthree\nfour"},
+		{"Escape with intermediates ignored", 10, 3, "a\033(Bb", "ab"},
+		{"Control sequence with intermediates ignored", 10, 3, "a\033[1 qb", "ab"},
+		{"Unknown private mode ignored", 10, 3, "\033[?5nHi", "Hi"},
+		{"Scroll region past bottom", 10, 3, "one\ntwo\nthree\033[2;99r\033[3H\nx", "one\nthree\nx"},
+		{"Alternate screen keeps cursor", 10, 3, "main\033[?47halt\033[?47l!", "main   !"},
+		{"Tab at right edge", 5, 3, "a\tb", "a   b"},
+		{"Column past right edge", 10, 3, "\033[99Gx", "         x"},
+		{"Row past bottom", 10, 3, "\033[99dx", "\n\nx"},
+		{"Cursor past top left", 10, 3, "ab\ncd\033[99A\033[99Dx", "xb\ncd"},
+		{"Invalid cursor position", 10, 3, "\033[2:3Hx", "x"},
+		{"Large insert characters", 10, 3, "Hello\033[1G\033[99@", ""},
+		{"Large delete characters", 10, 3, "Hello\033[2G\033[99P", "H"},
+		{"Large erase characters", 10, 3, "Hello\033[2G\033[99X", "H"},
+		{"Unknown escape ignored", 10, 3, "a\033Zb", "ab"},
+		{"Save and restore", 10, 3, "\0337\033[3;3Hx\0338y\033[2;2H\033[sz\033[u!", "y\n !\n  x"},
+		{"Alternate screen", 10, 3, "main\033[?1049hfull screen app\033[?1049l!", "main!"},
+		{"Reset", 10, 3, "Hello\033c", ""},
+		{"Styles ignored", 10, 3, "\033[1;31mRed\033[0m", "Red"},
+		{"Private sequences ignored", 10, 3, "\033[?25lHi\033[?25h", "Hi"},
+		{"Wide characters", 5, 3, "你好吗", "你好\n吗"},
+		{"Overwrite wide character", 5, 3, "你好\033[2Gx", " x好"},
+		{"Overwrite start of wide character", 5, 3, "你好\033[1Gx", "x 好"},
+		{"Wide character wider than screen", 1, 3, "你x", "你\nx"},
+		{"Zero width character", 10, 3, "a\u200bb", "ab"},
+		{"Emoji", 10, 3, "👩🏽‍🔧!", "👩🏽‍🔧!"},
+		{"Combining", 10, 3, "é!", "é!"},
+		{"Invalid sequence", 10, 3, "a\033[\x01b", "a[b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			screen := NewScreen(tt.width, tt.height)
+			n, err := screen.WriteString(tt.input)
+			is2.NoErr(err)
+			is2.Equal(n, len(tt.input))
+			is2.Equal(screen.String(), tt.want)
+		})
+	}
+}
+
+func TestScreenLines(t *testing.T) {
+	is2 := is.New(t)
+	screen := NewScreen(10, 3)
+	_, err := screen.WriteString("\033[1mBold\033[0m text\r\n\033[44m\033[K\033[31mRed")
+	is2.NoErr(err)
+	lines := screen.Lines()
+	is2.Equal(len(lines), 3)
+
+	is2.Equal(len(lines[0]), 2)
+	is2.Equal(lines[0][0].Label, "Bold")
+	is2.True(lines[0][0].Bold())
+	is2.Equal(lines[0][1].Label, " text")
+	is2.Equal(lines[0][1].Style, TextStyle(0))
+
+	// The erased line keeps the background colour
+	is2.Equal(len(lines[1]), 2)
+	is2.Equal(lines[1][0].Label, "Red")
+	is2.Equal(lines[1][0].FgCol.Name, "Maroon")
+	is2.Equal(lines[1][0].BgCol.Name, "Navy")
+	is2.Equal(lines[1][1].Label, "       ")
+	is2.True(lines[1][1].FgCol == nil)
+	is2.Equal(lines[1][1].BgCol.Name, "Navy")
+
+	is2.Equal(len(lines[2]), 0)
+}
+
+func TestScreenSplitWrites(t *testing.T) {
+	is2 := is.New(t)
+	input := "\033[1;31mHello\033]8;;http://a\a你好\033]8;;\a\033[2;1HWorld"
+	whole := NewScreen(10, 3)
+	_, err := whole.WriteString(input)
+	is2.NoErr(err)
+	for split := 1; split < len(input); split++ {
+		screen := NewScreen(10, 3)
+		_, err := screen.WriteString(input[:split])
+		is2.NoErr(err)
+		_, err = screen.WriteString(input[split:])
+		is2.NoErr(err)
+		is2.Equal(screen.String(), whole.String())
+		got, want := screen.Lines(), whole.Lines()
+		for y := range want {
+			is2.Equal(len(got[y]), len(want[y]))
+			for index := range want[y] {
+				is2.Equal(*got[y][index], *want[y][index])
+			}
+		}
+	}
+}
+
+func TestScreenCursor(t *testing.T) {
+	is2 := is.New(t)
+	screen := NewScreen(5, 3)
+	_, _ = screen.WriteString("Hello")
+	x, y := screen.Cursor()
+	is2.Equal(x, 4)
+	is2.Equal(y, 0)
+	is2.Equal(screen.Cell(4, 0).Char, "o")
+	_, _ = screen.WriteString("\033[10;10H")
+	x, y = screen.Cursor()
+	is2.Equal(x, 4)
+	is2.Equal(y, 2)
+
+	// Restoring the cursor restores its style
+	_, _ = screen.WriteString("\033[31m\0337\033[0m\033[H\0338x")
+	is2.Equal(screen.Cell(4, 2).Char, "x")
+	is2.Equal(screen.Cell(4, 2).Style.FgCol, Cols[1])
+}
+
+func TestScreenSize(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name          string
+		width, height int
+		wantWidth     int
+		wantHeight    int
+	}{
+		{"Size", 80, 24, 80, 24},
+		{"Empty", 0, 0, 1, 1},
+		{"Negative", -5, -1, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			screen := NewScreen(tt.width, tt.height)
+			is2.Equal(screen.Width(), tt.wantWidth)
+			is2.Equal(screen.Height(), tt.wantHeight)
+			is2.Equal(len(screen.Lines()), tt.wantHeight)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package ansi
+
+import "strings"
+
+// indexSequence returns the index of the first control sequence
+// (ESC [) or operating system command (ESC ]) in input, or -1 if
+// there is none. Other uses of ESC are treated as text.
+func indexSequence(input string) int {
+	offset := 0
+	for {
+		esc := strings.IndexByte(input[offset:], '\033')
+		if esc == -1 {
+			return -1
+		}
+		esc += offset
+		if esc+1 < len(input) && (input[esc+1] == '[' || input[esc+1] == ']') {
+			return esc
+		}
+		offset = esc + 1
+	}
+}
+
+// csiSequence is a control sequence introduced by ESC [.
+// See ECMA-48, section 5.4.
+type csiSequence struct {
+	// params holds the parameter bytes (0x30–0x3F)
+	params string
+	// intermediates holds the intermediate bytes (0x20–0x2F)
+	intermediates string
+	// final is the final byte (0x40–0x7E)
+	final byte
+}
+
+// isSGR returns true if the sequence is a Select Graphic Rendition
+// sequence. Private sequences, where the parameters start with
+// one of "<=>?", are not SGR sequences.
+func (c csiSequence) isSGR() bool {
+	if c.final != 'm' || c.intermediates != "" {
+		return false
+	}
+	return len(c.params) == 0 || c.params[0] < '<'
+}
+
+// scanCSI reads the control sequence at the start of input, which
+// must begin with ESC [. It returns the sequence and its length in
+// bytes. If input ends before the final byte, ErrMissingTerminator is
+// returned. A byte outside the ranges allowed by ECMA-48 results
+// in ErrInvalid. On error, the length covers the bytes read, up to
+// any ESC that interrupts the sequence.
+func scanCSI(input string) (csiSequence, int, error) {
+	var result csiSequence
+	index := 2
+	paramsEnd := -1
+	for ; index < len(input); index++ {
+		b := input[index]
+		switch {
+		case b >= 0x30 && b <= 0x3f:
+			// Parameter bytes may not follow intermediate bytes
+			if paramsEnd != -1 {
+				return result, index + 1, ErrInvalid
+			}
+		case b >= 0x20 && b <= 0x2f:
+			if paramsEnd == -1 {
+				paramsEnd = index
+			}
+		case b >= 0x40 && b <= 0x7e:
+			if paramsEnd == -1 {
+				paramsEnd = index
+			}
+			result.params = input[2:paramsEnd]
+			result.intermediates = input[paramsEnd:index]
+			result.final = b
+			return result, index + 1, nil
+		case b == '\033':
+			return result, index, ErrInvalid
+		default:
+			return result, index + 1, ErrInvalid
+		}
+	}
+	return result, len(input), ErrMissingTerminator
+}
+
+// oscSequence is an operating system command introduced by ESC ].
+// See ECMA-48, section 8.3.89.
+type oscSequence struct {
+	// command is the numeric command before the first ';'
+	command string
+	// data holds the remainder of the command string
+	data string
+}
+
+// hyperlink returns the link described by an OSC 8 sequence,
+// or nil if the sequence ends a link
+func (o oscSequence) hyperlink() *Hyperlink {
+	separator := strings.IndexByte(o.data, ';')
+	if separator == -1 {
+		return nil
+	}
+	params, url := o.data[:separator], o.data[separator+1:]
+	if url == "" {
+		return nil
+	}
+	result := &Hyperlink{URL: url}
+	for params != "" {
+		param := params
+		if separator := strings.IndexByte(params, ':'); separator != -1 {
+			param, params = params[:separator], params[separator+1:]
+		} else {
+			params = ""
+		}
+		if strings.HasPrefix(param, "id=") {
+			result.ID = param[3:]
+		}
+	}
+	return result
+}
+
+// scanOSC reads the operating system command at the start of input,
+// which must begin with ESC ]. It returns the sequence and its length
+// in bytes. If input ends before the terminator, ErrMissingTerminator
+// is returned.
+func scanOSC(input string) (oscSequence, int, error) {
+	var result oscSequence
+	body, length, err := scanString(input)
+	if err != nil {
+		return result, length, err
+	}
+	result.command = body
+	if separator := strings.IndexByte(body, ';'); separator != -1 {
+		result.command = body[:separator]
+		result.data = body[separator+1:]
+	}
+	return result, length, nil
+}
+
+// scanString reads the control string at the start of input, which
+// must begin with ESC and a byte that opens a control string, such
+// as ']' for OSC. The string may be terminated by either BEL or
+// ST (ESC \). It returns the body of the string and the length of
+// the whole sequence in bytes. On error, the length covers the
+// bytes read, up to any ESC that interrupts the string.
+func scanString(input string) (string, int, error) {
+	for index := 2; index < len(input); index++ {
+		switch input[index] {
+		case '\a':
+			return input[2:index], index + 1, nil
+		case '\033':
+			if index+1 == len(input) {
+				return "", len(input), ErrMissingTerminator
+			}
+			if input[index+1] != '\\' {
+				return "", index, ErrInvalid
+			}
+			return input[2:index], index + 2, nil
+		}
+	}
+	return "", len(input), ErrMissingTerminator
+}
+
+// scanEscape reads the escape sequence at the start of input, which
+// must begin with ESC and is neither a control sequence nor an
+// operating system command. DCS, SOS, PM and APC control strings are
+// read up to their terminator, other sequences up to their final
+// byte. It returns the final byte, if any, and the length of the
+// sequence in bytes. On error, the length covers the bytes read, up
+// to any ESC that interrupts the sequence.
+func scanEscape(input string) (byte, int, error) {
+	if len(input) < 2 {
+		return 0, len(input), ErrMissingTerminator
+	}
+	switch input[1] {
+	case 'P', 'X', '^', '_':
+		_, length, err := scanString(input)
+		return 0, length, err
+	}
+	for index := 1; index < len(input); index++ {
+		b := input[index]
+		switch {
+		case b >= 0x20 && b <= 0x2f:
+		case b >= 0x30 && b <= 0x7e:
+			return b, index + 1, nil
+		case b == '\033':
+			return 0, index, ErrInvalid
+		default:
+			return 0, index + 1, ErrInvalid
+		}
+	}
+	return 0, len(input), ErrMissingTerminator
+}
@@ -0,0 +1,34 @@
+package ansi
+
+import (
+	"testing"
+
+	is "github.com/matryer/is"
+)
+
+func TestScanEscape(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name       string
+		input      string
+		wantFinal  byte
+		wantLength int
+		wantErr    error
+	}{
+		{"Final", "\0337text", '7', 2, nil},
+		{"Intermediates", "\033(Btext", 'B', 3, nil},
+		{"Control string", "\033Pdata\033\\text", 0, 8, nil},
+		{"Lone ESC", "\033", 0, 1, ErrMissingTerminator},
+		{"Missing final", "\033(", 0, 2, ErrMissingTerminator},
+		{"Interrupted", "\033(\033[1m", 0, 2, ErrInvalid},
+		{"Bad byte", "\033(\x01", 0, 3, ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			final, length, err := scanEscape(tt.input)
+			is2.Equal(final, tt.wantFinal)
+			is2.Equal(length, tt.wantLength)
+			is2.Equal(err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package ansi
+
+import "io"
+
+// stripState is the state of the escape sequence recogniser
+// used by StripWriter
+type stripState int
+
+const (
+	stripGround stripState = iota
+	stripEscape
+	stripEscapeIntermediate
+	stripCSI
+	stripString
+	stripStringEscape
+)
+
+// StripWriter is an io.Writer that removes ANSI escape sequences
+// from everything written to it before passing it on to the
+// underlying writer. Sequences may be split across calls to Write.
+type StripWriter struct {
+	w     io.Writer
+	state stripState
+	out   []byte
+}
+
+// NewStripWriter returns a new StripWriter that writes the text
+// written to it, without escape sequences, to w.
+func NewStripWriter(w io.Writer) *StripWriter {
+	return &StripWriter{w: w}
+}
+
+// Write removes the escape sequences from p and writes the
+// remaining text to the underlying writer.
+// An incomplete escape sequence at the end of p is remembered,
+// so that the rest of it is removed from the next call to Write.
+func (s *StripWriter) Write(p []byte) (int, error) {
+	s.out = s.out[:0]
+	for _, b := range p {
+		s.next(b)
+	}
+	if len(s.out) == 0 {
+		return len(p), nil
+	}
+	_, err := s.w.Write(s.out)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// next advances the state machine with a single byte, adding it
+// to the output if it is not part of an escape sequence
+func (s *StripWriter) next(b byte) {
+	switch s.state {
+	case stripGround:
+		if b == '\033' {
+			s.state = stripEscape
+			return
+		}
+		s.out = append(s.out, b)
+	case stripEscape:
+		switch {
+		case b == '[':
+			s.state = stripCSI
+		case b == ']', b == 'P', b == 'X', b == '^', b == '_':
+			// OSC, DCS, SOS, PM and APC are terminated by ST
+			s.state = stripString
+		case b == '\033':
+		case b < 0x20:
+			// Control characters are executed within a sequence
+			s.out = append(s.out, b)
+		case b < 0x30:
+			s.state = stripEscapeIntermediate
+		case b < 0x7f:
+			s.state = stripGround
+		default:
+			s.state = stripGround
+			s.out = append(s.out, b)
+		}
+	case stripEscapeIntermediate:
+		switch {
+		case b == '\033':
+			s.state = stripEscape
+		case b < 0x20:
+			s.out = append(s.out, b)
+		case b >= 0x30:
+			s.state = stripGround
+		}
+	case stripCSI:
+		switch {
+		case b == '\033':
+			s.state = stripEscape
+		case b < 0x20:
+			s.out = append(s.out, b)
+		case b >= 0x40:
+			s.state = stripGround
+		}
+	case stripString:
+		switch b {
+		case '\a':
+			s.state = stripGround
+		case '\033':
+			s.state = stripStringEscape
+		}
+	case stripStringEscape:
+		if b == '\\' {
+			s.state = stripGround
+			return
+		}
+		// Not a string terminator: start of a new sequence
+		s.state = stripEscape
+		s.next(b)
+	}
+}
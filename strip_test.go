@@ -0,0 +1,83 @@
+package ansi
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	is "github.com/matryer/is"
+)
+
+func TestStripWriter(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Blank", "", ""},
+		{"No formatting", "Hello World", "Hello World"},
+		{"ANSI16 Fg", "\033[0;31mRed\033[0m", "Red"},
+		{"Red Bold & Black", "\u001b[0;1;31mI am Red\033[0m & \u001B[0;30mI am Black\u001B[0m", "I am Red & I am Black"},
+		{"TrueColor", "\u001B[38;2;255;0;0mRed\u001B[0mI am plain text", "RedI am plain text"},
+		{"Emoji", "\u001B[0;1;31m😀👩🏽‍🔧\u001B[0m", "😀👩🏽‍🔧"},
+		{"Cursor movement", "\033[2K\033[1Gdone \033[0m", "done "},
+		{"Private mode", "\033[?25lhidden\033[?25h", "hidden"},
+		{"Hyperlink BEL", "\033]8;;https://example.com\aExample\033]8;;\a", "Example"},
+		{"Hyperlink ST", "\033]8;id=1;https://example.com\033\\Example\033]8;;\033\\", "Example"},
+		{"Window title", "\033]0;title\007text", "text"},
+		{"Charset", "\033(Btext\033=", "text"},
+		{"Save cursor", "\0337text\0338", "text"},
+		{"Newlines kept", "one\033[31m\ntwo\r\n", "one\ntwo\r\n"},
+		{"Incomplete", "text\033[31", "text"},
+		{"Device control string", "\033P1$r0m\033\\text", "text"},
+		{"Application program command", "\033_payload\033\\text", "text"},
+		{"Start of string and privacy message", "\033Xsos\033\\a\033^pm\a b", "a b"},
+		{"String ended by sequence", "\033]0;title\033[31mtext", "text"},
+		{"Escape escape", "a\033\033[31mb", "ab"},
+		{"Control in escape", "a\033\nBb", "a\nb"},
+		{"Escape before non-ASCII", "a\033é", "aé"},
+		{"Escape before delete", "a\033\x7fb", "a\x7fb"},
+		{"Intermediates", "\033#8\033 Ftext", "text"},
+		{"Escape in intermediates", "a\033(\033[31mb", "ab"},
+		{"Control in intermediates", "a\033(\nBb", "a\nb"},
+		{"Escape in control sequence", "a\033[3\033[31mb", "ab"},
+		{"Control in control sequence", "a\033[3\n1mb", "a\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var whole bytes.Buffer
+			w := NewStripWriter(&whole)
+			n, err := w.Write([]byte(tt.input))
+			is2.NoErr(err)
+			is2.Equal(n, len(tt.input))
+			is2.Equal(whole.String(), tt.want)
+
+			// Byte at a time
+			var split bytes.Buffer
+			w = NewStripWriter(&split)
+			for i := 0; i < len(tt.input); i++ {
+				_, err := w.Write([]byte{tt.input[i]})
+				is2.NoErr(err)
+			}
+			is2.Equal(split.String(), tt.want)
+		})
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestStripWriterError(t *testing.T) {
+	is2 := is.New(t)
+	w := NewStripWriter(errWriter{})
+	n, err := w.Write([]byte("\033[31m"))
+	is2.NoErr(err)
+	is2.Equal(n, 5)
+	n, err = w.Write([]byte("Red"))
+	is2.True(err != nil)
+	is2.Equal(n, 0)
+}
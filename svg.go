@@ -0,0 +1,210 @@
+package ansi
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// The sizes used by SVG, in pixels. Monospace fonts are close to
+// 0.6em wide, so each cell is 9 pixels wide with a 15 pixel font.
+const (
+	svgFontSize   = 15
+	svgCellWidth  = 9
+	svgLineHeight = 18
+	// svgBaseline is the offset of the baseline from the top of a line
+	svgBaseline = 14
+	svgPadding  = 12
+	// svgTitleBar is the height of the window title bar
+	svgTitleBar = 36
+)
+
+// windowButtons are the colours of the window buttons
+var windowButtons = []*Col{
+	{Rgb: Rgb{R: 0xff, G: 0x5f, B: 0x56}},
+	{Rgb: Rgb{R: 0xff, G: 0xbd, B: 0x2e}},
+	{Rgb: Rgb{R: 0x27, G: 0xc9, B: 0x3f}},
+}
+
+// svgFontFamily is the list of monospace fonts used for text
+const svgFontFamily = `ui-monospace,SFMono-Regular,Menlo,Consolas,"DejaVu Sans Mono",monospace`
+
+// SVG renders an ansi encoded string as an SVG image that looks like
+// a screenshot of a terminal. Each character is placed in a grid of
+// monospace cells, using its display width, so wide characters such
+// as emoji take up two cells. Lines end at each newline, tabs move to
+// the next multiple of 8 columns and other control characters are
+// removed, so Collapse should be used first for output that redraws
+// lines.
+// Colours, bold, faint, italic, underline, strikethrough, overline,
+// inversed and invisible text are drawn. The image is self contained,
+// apart from the font, which is the first of the usual monospace
+// fonts that is installed where it is viewed.
+// If parsing is unsuccessful, a *ParseError is returned.
+func SVG(input string, options ...RenderOption) (string, error) {
+	config := newRenderConfig(options)
+	lines, err := layout(input, config.parseOptions)
+	if err != nil {
+		return "", err
+	}
+
+	width := layoutWidth(lines)*svgCellWidth + 2*svgPadding
+	top := svgPadding
+	radius := 0
+	if config.window {
+		top += svgTitleBar
+		radius = 8
+		// Leave room for the buttons either side of the title
+		titleWidth := uniseg.StringWidth(config.title)*svgCellWidth + 2*(svgPadding+len(windowButtons)*20)
+		if width < titleWidth {
+			width = titleWidth
+		}
+	}
+	height := top + len(lines)*svgLineHeight + svgPadding
+
+	var result strings.Builder
+	fmt.Fprintf(&result, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" xml:space="preserve">`+"\n", width, height, width, height)
+	fmt.Fprintf(&result, "<style>text{font-family:%s;font-size:%dpx;white-space:pre}</style>\n", svgFontFamily, svgFontSize)
+	fmt.Fprintf(&result, `<rect width="%d" height="%d" rx="%d" fill="%s"/>`+"\n", width, height, radius, colourHex(config.background))
+	if config.window {
+		for i, colour := range windowButtons {
+			fmt.Fprintf(&result, `<circle cx="%d" cy="%d" r="6" fill="%s"/>`+"\n", svgPadding+6+i*20, svgTitleBar/2, colourHex(colour))
+		}
+		if config.title != "" {
+			fmt.Fprintf(&result, `<text x="%d" y="%d" text-anchor="middle" fill="%s">%s</text>`+"\n",
+				width/2, svgTitleBar/2+5, colourHex(config.foreground), html.EscapeString(config.title))
+		}
+	}
+	for row, line := range lines {
+		config.writeSVGLine(&result, line, svgPadding, top+row*svgLineHeight)
+	}
+	result.WriteString("</svg>\n")
+	return result.String(), nil
+}
+
+// writeSVGLine writes the backgrounds, text and lines for a line
+// of cells, with its top left corner at x, y
+func (c *renderConfig) writeSVGLine(result *strings.Builder, line []layoutCell, x, y int) {
+	rect := func(run cellRun, offset, height int, colour *Col) {
+		fmt.Fprintf(result, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+			x+run.column*svgCellWidth, y+offset, run.width*svgCellWidth, height, colourHex(colour))
+	}
+
+	backgrounds := cellRuns(line, func(cell *layoutCell, column int) (string, bool) {
+		_, bg := c.cellColours(cell.style)
+		if bg == nil {
+			return "", false
+		}
+		return colourHex(bg), true
+	})
+	for _, run := range backgrounds {
+		_, bg := c.cellColours(run.cells[0].style)
+		rect(run, 0, svgLineHeight, bg)
+	}
+
+	texts := cellRuns(line, func(cell *layoutCell, column int) (string, bool) {
+		if cell.style.Invisible() {
+			return "", false
+		}
+		fg, _ := c.cellColours(cell.style)
+		key := fmt.Sprintf("%s %v %v", colourHex(fg), cell.style.Bold(), cell.style.Italic())
+		if cell.width > 1 {
+			// Wide characters are placed on their own
+			key += " " + strconv.Itoa(column)
+		}
+		return key, true
+	})
+	for _, run := range texts {
+		var text strings.Builder
+		for _, cell := range run.cells {
+			text.WriteString(cell.char)
+		}
+		if strings.TrimLeft(text.String(), " ") == "" {
+			continue
+		}
+		style := run.cells[0].style
+		fg, _ := c.cellColours(style)
+		fmt.Fprintf(result, `<text x="%d" y="%d" fill="%s"`, x+run.column*svgCellWidth, y+svgBaseline, colourHex(fg))
+		if style.Bold() {
+			result.WriteString(` font-weight="bold"`)
+		}
+		if style.Italic() {
+			result.WriteString(` font-style="italic"`)
+		}
+		fmt.Fprintf(result, ` textLength="%d">%s</text>`+"\n", run.width*svgCellWidth, html.EscapeString(text.String()))
+	}
+
+	// Underlines use the underline colour, if one is set
+	for _, kind := range textLines {
+		runs := cellRuns(line, func(cell *layoutCell, column int) (string, bool) {
+			colour, ok := c.lineColour(cell.style, kind)
+			if !ok {
+				return "", false
+			}
+			return fmt.Sprint(colourHex(colour), kind.offsets(cell.style, svgBaseline)), true
+		})
+		for _, run := range runs {
+			colour, _ := c.lineColour(run.cells[0].style, kind)
+			for _, offset := range kind.offsets(run.cells[0].style, svgBaseline) {
+				rect(run, offset, 1, colour)
+			}
+		}
+	}
+}
+
+// textLine is one of the lines drawn through or around text
+type textLine int
+
+const (
+	underline textLine = iota
+	strikethrough
+	overline
+)
+
+// textLines holds the kinds of line
+var textLines = []textLine{underline, strikethrough, overline}
+
+// lineColour returns the colour of the line of the given kind for
+// the style of s, or false if there is no line
+func (c *renderConfig) lineColour(s *StyledText, kind textLine) (*Col, bool) {
+	if s.Invisible() {
+		return nil, false
+	}
+	fg, _ := c.cellColours(s)
+	switch kind {
+	case underline:
+		if !s.Underlined() && s.Underline == UnderlineNone {
+			return nil, false
+		}
+		if s.UlCol != nil {
+			return s.UlCol, true
+		}
+	case strikethrough:
+		if !s.Strikethrough() {
+			return nil, false
+		}
+	case overline:
+		if !s.Overlined() {
+			return nil, false
+		}
+	}
+	return fg, true
+}
+
+// offsets returns the offsets from the top of a line of text of the
+// lines of the given kind, where baseline is the offset of the baseline
+func (kind textLine) offsets(s *StyledText, baseline int) []int {
+	switch kind {
+	case underline:
+		if s.Underline == UnderlineDouble {
+			return []int{baseline + 1, baseline + 3}
+		}
+		return []int{baseline + 2}
+	case strikethrough:
+		return []int{baseline - 5}
+	}
+	return []int{1}
+}
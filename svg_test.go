@@ -0,0 +1,156 @@
+package ansi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSVG(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name    string
+		input   string
+		options []RenderOption
+		want    []string
+		notWant []string
+	}{
+		{
+			name:  "Size",
+			input: "Hello\nWorld!\n",
+			want: []string{
+				`<svg xmlns="http://www.w3.org/2000/svg" width="78" height="60" viewBox="0 0 78 60" xml:space="preserve">`,
+				`<rect width="78" height="60" rx="0" fill="#000000"/>`,
+				`<text x="12" y="26" fill="#c0c0c0" textLength="45">Hello</text>`,
+				`<text x="12" y="44" fill="#c0c0c0" textLength="54">World!</text>`,
+			},
+			notWant: []string{"<circle"},
+		},
+		{
+			name:  "Escaped",
+			input: "<a & b>",
+			want:  []string{`textLength="63">&lt;a &amp; b&gt;</text>`},
+		},
+		{
+			name:  "Styles",
+			input: "\033[1;3;31mBold\033[0m",
+			want:  []string{`<text x="12" y="26" fill="#ff0000" font-weight="bold" font-style="italic" textLength="36">Bold</text>`},
+		},
+		{
+			name:  "Backgrounds",
+			input: "\033[44m  \033[42m \033[0m ",
+			want: []string{
+				`<rect x="12" y="12" width="18" height="18" fill="#000080"/>`,
+				`<rect x="30" y="12" width="9" height="18" fill="#008000"/>`,
+			},
+			notWant: []string{"<text"},
+		},
+		{
+			name:  "Wide characters",
+			input: "a😀b",
+			want: []string{
+				`<text x="12" y="26" fill="#c0c0c0" textLength="9">a</text>`,
+				`<text x="21" y="26" fill="#c0c0c0" textLength="18">😀</text>`,
+				`<text x="39" y="26" fill="#c0c0c0" textLength="9">b</text>`,
+			},
+		},
+		{
+			name:  "Tabs",
+			input: "a\tb",
+			want:  []string{`<text x="12" y="26" fill="#c0c0c0" textLength="81">a       b</text>`},
+		},
+		{
+			name:  "Inversed",
+			input: "\033[7mInv",
+			want: []string{
+				`<rect x="12" y="12" width="27" height="18" fill="#c0c0c0"/>`,
+				`<text x="12" y="26" fill="#000000" textLength="27">Inv</text>`,
+			},
+		},
+		{
+			name:    "Invisible",
+			input:   "\033[8;4mSecret",
+			notWant: []string{"Secret", `height="1"`},
+		},
+		{
+			name:  "Faint",
+			input: "\033[2mFaint",
+			want:  []string{`fill="#606060"`},
+		},
+		{
+			name:  "Lines",
+			input: "\033[4;58;5;1ma\033[0;9mb\033[0;53mc\033[0;21md",
+			want: []string{
+				`<rect x="12" y="28" width="9" height="1" fill="#800000"/>`,
+				`<rect x="21" y="21" width="9" height="1" fill="#c0c0c0"/>`,
+				`<rect x="30" y="13" width="9" height="1" fill="#c0c0c0"/>`,
+				`<rect x="39" y="27" width="9" height="1" fill="#c0c0c0"/>`,
+				`<rect x="39" y="29" width="9" height="1" fill="#c0c0c0"/>`,
+			},
+		},
+		{
+			name:    "Window",
+			input:   "ls",
+			options: []RenderOption{WithWindow("<Terminal>")},
+			want: []string{
+				`width="234" height="78"`,
+				`rx="8"`,
+				`<circle cx="18" cy="18" r="6" fill="#ff5f56"/>`,
+				`<text x="117" y="23" text-anchor="middle" fill="#c0c0c0">&lt;Terminal&gt;</text>`,
+				`<text x="12" y="62" fill="#c0c0c0" textLength="18">ls</text>`,
+			},
+		},
+		{
+			name:    "Window without title",
+			input:   "ls",
+			options: []RenderOption{WithWindow("")},
+			want:    []string{`<circle cx="58" cy="18" r="6" fill="#27c93f"/>`},
+			notWant: []string{"text-anchor"},
+		},
+		{
+			name:    "Terminal colours",
+			input:   "Text",
+			options: []RenderOption{WithTerminalColours(&Col{Rgb: Rgb{R: 1, G: 2, B: 3}}, &Col{Rgb: Rgb{R: 255, G: 255, B: 255}})},
+			want: []string{
+				`fill="#ffffff"/>`,
+				`<text x="12" y="26" fill="#010203" textLength="36">Text</text>`,
+			},
+		},
+		{
+			name:    "Palette",
+			input:   "\033[31mRed",
+			options: []RenderOption{WithRenderPalette([]*Col{{Rgb: Rgb{R: 16, G: 16, B: 16}}, {Rgb: Rgb{R: 200, G: 10, B: 10}}})},
+			want: []string{
+				`fill="#101010"/>`,
+				`<text x="12" y="26" fill="#c80a0a" textLength="27">Red</text>`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SVG(tt.input, tt.options...)
+			is2.NoErr(err)
+			is2.True(strings.HasSuffix(got, "</svg>\n"))
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("%q not found in %s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("%q found in %s", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSVGErrors(t *testing.T) {
+	is2 := is.New(t)
+	_, err := SVG("\033[38;5;300mText")
+	is2.True(errors.Is(err, ErrInvalid256ColSequence))
+	_, err = SVG("\033[99mText", WithRenderParseOptions(WithIgnoreInvalidCodes()))
+	is2.NoErr(err)
+}
@@ -0,0 +1,127 @@
+package ansi
+
+// TokenType is a type representing the
+// kinds of token in an ANSI encoded string
+type TokenType int
+
+const (
+	// TextToken is a run of text without control characters
+	TextToken TokenType = iota
+	// SGRToken is a Select Graphic Rendition control sequence
+	SGRToken
+	// CSIToken is a control sequence other than SGR
+	CSIToken
+	// OSCToken is an operating system command
+	OSCToken
+	// ESCToken is any other escape sequence
+	ESCToken
+	// ControlToken is a single C0 control character
+	ControlToken
+)
+
+// Token is a single element of an ANSI encoded string
+type Token struct {
+	Type TokenType
+	// Raw holds the exact bytes of the token in the input string
+	Raw string
+	// Offset is the offset into the input string where the token begins
+	Offset int
+	// Params holds the parameter bytes of SGR and CSI tokens and
+	// the command string of OSC tokens
+	Params string
+	// Intermediates holds the intermediate bytes of SGR and CSI tokens
+	Intermediates string
+	// Final is the final byte of SGR, CSI and ESC tokens
+	Final byte
+}
+
+// Tokenize splits an ansi encoded string into tokens, keeping
+// the exact bytes of each one, so that joining the Raw fields
+// of the tokens reproduces the input.
+// If the input contains an incomplete or malformed escape
+// sequence, a *ParseError is returned.
+func Tokenize(input string) ([]*Token, error) {
+	result, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// tokenize splits input into tokens, stopping at the first
+// malformed escape sequence. On error, the tokens before the
+// sequence are returned with the error.
+func tokenize(input string) ([]*Token, error) {
+	var result []*Token
+	index := 0
+	for index < len(input) {
+		token := &Token{Offset: index}
+		length := 0
+		b := input[index]
+		switch {
+		case b == '\033':
+			var err error
+			length, err = scanToken(input[index:], token)
+			if err != nil {
+				return result, &ParseError{
+					Offset:   index,
+					Sequence: input[index : index+length],
+					Kind:     err,
+				}
+			}
+		case b < 0x20:
+			token.Type = ControlToken
+			length = 1
+		default:
+			token.Type = TextToken
+			for length = 1; index+length < len(input); length++ {
+				if input[index+length] < 0x20 {
+					break
+				}
+			}
+		}
+		token.Raw = input[index : index+length]
+		result = append(result, token)
+		index += length
+	}
+	return result, nil
+}
+
+// scanToken reads the escape sequence at the start of input
+// into token and returns its length in bytes. On error, the length
+// covers the bytes read.
+func scanToken(input string, token *Token) (int, error) {
+	if len(input) < 2 {
+		return len(input), ErrMissingTerminator
+	}
+	switch input[1] {
+	case '[':
+		csi, length, err := scanCSI(input)
+		if err != nil {
+			return length, err
+		}
+		token.Type = CSIToken
+		if csi.isSGR() {
+			token.Type = SGRToken
+		}
+		token.Params = csi.params
+		token.Intermediates = csi.intermediates
+		token.Final = csi.final
+		return length, nil
+	case ']':
+		body, length, err := scanString(input)
+		if err != nil {
+			return length, err
+		}
+		token.Type = OSCToken
+		token.Params = body
+		return length, nil
+	}
+	final, length, err := scanEscape(input)
+	if err != nil {
+		return length, err
+	}
+	token.Type = ESCToken
+	token.Final = final
+	return length, nil
+}
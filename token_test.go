@@ -0,0 +1,81 @@
+package ansi
+
+import (
+	"strings"
+	"testing"
+
+	is "github.com/matryer/is"
+)
+
+func TestTokenize(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name    string
+		input   string
+		want    []*Token
+		wantErr bool
+	}{
+		{"Blank", "", nil, false},
+		{"No formatting", "Hello World", []*Token{
+			{Type: TextToken, Raw: "Hello World"},
+		}, false},
+		{"SGR", "\033[0;1;31mRed\033[m", []*Token{
+			{Type: SGRToken, Raw: "\033[0;1;31m", Params: "0;1;31", Final: 'm'},
+			{Type: TextToken, Raw: "Red", Offset: 9},
+			{Type: SGRToken, Raw: "\033[m", Offset: 12, Final: 'm'},
+		}, false},
+		{"CSI", "\033[2K\033[?25l\033[0 q", []*Token{
+			{Type: CSIToken, Raw: "\033[2K", Params: "2", Final: 'K'},
+			{Type: CSIToken, Raw: "\033[?25l", Offset: 4, Params: "?25", Final: 'l'},
+			{Type: CSIToken, Raw: "\033[0 q", Offset: 10, Params: "0", Intermediates: " ", Final: 'q'},
+		}, false},
+		{"OSC", "\033]8;;http://a\aa\033]0;title\033\\", []*Token{
+			{Type: OSCToken, Raw: "\033]8;;http://a\a", Params: "8;;http://a"},
+			{Type: TextToken, Raw: "a", Offset: 14},
+			{Type: OSCToken, Raw: "\033]0;title\033\\", Offset: 15, Params: "0;title"},
+		}, false},
+		{"ESC", "\0337\033(B\033Pq#0\033\\\0338", []*Token{
+			{Type: ESCToken, Raw: "\0337", Final: '7'},
+			{Type: ESCToken, Raw: "\033(B", Offset: 2, Final: 'B'},
+			{Type: ESCToken, Raw: "\033Pq#0\033\\", Offset: 5},
+			{Type: ESCToken, Raw: "\0338", Offset: 12, Final: '8'},
+		}, false},
+		{"Controls", "one\r\ntwo\b\a", []*Token{
+			{Type: TextToken, Raw: "one"},
+			{Type: ControlToken, Raw: "\r", Offset: 3},
+			{Type: ControlToken, Raw: "\n", Offset: 4},
+			{Type: TextToken, Raw: "two", Offset: 5},
+			{Type: ControlToken, Raw: "\b", Offset: 8},
+			{Type: ControlToken, Raw: "\a", Offset: 9},
+		}, false},
+		{"Emoji", "😀\033[1m👩🏽‍🔧", []*Token{
+			{Type: TextToken, Raw: "😀"},
+			{Type: SGRToken, Raw: "\033[1m", Offset: 4, Params: "1", Final: 'm'},
+			{Type: TextToken, Raw: "👩🏽‍🔧", Offset: 8},
+		}, false},
+		{"Missing terminator", "text\033[31", nil, true},
+		{"Missing OSC terminator", "text\033]8;;http://a", nil, true},
+		{"Trailing ESC", "text\033", nil, true},
+		{"Trailing intermediate", "text\033(", nil, true},
+		{"Interrupted escape", "text\033(\033[1m", nil, true},
+		{"Bad escape", "\033\x80", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Tokenize(tt.input)
+			is2.Equal(err != nil, tt.wantErr)
+			is2.Equal(len(got), len(tt.want))
+			for index, w := range tt.want {
+				is2.Equal(*got[index], *w)
+			}
+			if tt.wantErr {
+				return
+			}
+			var joined strings.Builder
+			for _, token := range got {
+				joined.WriteString(token.Raw)
+			}
+			is2.Equal(joined.String(), tt.input)
+		})
+	}
+}
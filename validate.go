@@ -0,0 +1,258 @@
+package ansi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Severity is the severity of a Diagnostic
+type Severity int
+
+const (
+	// SeverityError is a problem that makes Parse fail
+	SeverityError Severity = iota
+	// SeverityWarning is valid input that is likely to be a mistake
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Diagnostic describes a problem found by Validate
+type Diagnostic struct {
+	Severity Severity
+	// Offset is the offset in bytes into the input where the
+	// sequence begins
+	Offset int
+	// Sequence holds the bytes of the sequence
+	Sequence string
+	// Param is the SGR parameter the diagnostic applies to, if any
+	Param string
+	// Kind is the kind of error, eg. ErrInvalid256ColSequence.
+	// It is nil for warnings.
+	Kind    error
+	Message string
+}
+
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%d: %v: %s", d.Offset, d.Severity, d.Message)
+}
+
+// sgrAttribute is a set of the attributes affected by an SGR parameter
+type sgrAttribute uint32
+
+const (
+	attrBold sgrAttribute = 1 << iota
+	attrFaint
+	attrItalic
+	attrUnderline
+	attrBlink
+	attrInverse
+	attrInvisible
+	attrStrikethrough
+	attrFont
+	attrFraktur
+	attrFrame
+	attrOverline
+	attrPosition
+	attrForeground
+	attrBackground
+	attrUnderlineColour
+
+	attrAll sgrAttribute = 1<<iota - 1
+)
+
+// sgrAttributes returns the attributes set or reset by an SGR parameter
+func sgrAttributes(param string) sgrAttribute {
+	// Extended colours and sub-parameters are identified by their first part
+	if separator := strings.IndexAny(param, ":;"); separator != -1 {
+		param = param[:separator]
+	}
+	switch stripLeadingZeros(param) {
+	case "0", "":
+		return attrAll
+	case "1":
+		return attrBold
+	case "2":
+		return attrFaint
+	case "22":
+		return attrBold | attrFaint
+	case "3":
+		return attrItalic
+	case "23":
+		return attrItalic | attrFraktur
+	case "4", "21", "24":
+		return attrUnderline
+	case "5", "6", "25":
+		return attrBlink
+	case "7", "27":
+		return attrInverse
+	case "8", "28":
+		return attrInvisible
+	case "9", "29":
+		return attrStrikethrough
+	case "10", "11", "12", "13", "14", "15", "16", "17", "18", "19":
+		return attrFont
+	case "20":
+		return attrFraktur
+	case "51", "52", "54":
+		return attrFrame
+	case "53", "55":
+		return attrOverline
+	case "73", "74", "75":
+		return attrPosition
+	case "30", "31", "32", "33", "34", "35", "36", "37", "38", "39",
+		"90", "91", "92", "93", "94", "95", "96", "97":
+		return attrForeground
+	case "40", "41", "42", "43", "44", "45", "46", "47", "48", "49",
+		"100", "101", "102", "103", "104", "105", "106", "107":
+		return attrBackground
+	case "58", "59":
+		return attrUnderlineColour
+	}
+	return 0
+}
+
+// isResetParam returns true if the SGR parameter turns attributes off
+func isResetParam(param string) bool {
+	switch stripLeadingZeros(param) {
+	case "0", "", "10", "22", "23", "24", "25", "27", "28", "29",
+		"39", "49", "54", "55", "59", "75":
+		return true
+	}
+	return false
+}
+
+// pendingParam is an SGR parameter that has not yet been
+// applied to any text
+type pendingParam struct {
+	offset     int
+	sequence   string
+	param      string
+	attributes sgrAttribute
+}
+
+// Validate checks input for problems, carrying on past each one to
+// report every problem found. Errors are problems that would make
+// Parse fail: unknown SGR parameters, out of range colours, malformed
+// and unterminated sequences. Warnings are given for resets of
+// attributes that are not set, and for attributes that are set and
+// then overridden before any text uses them.
+// Validate returns nil if no problems are found.
+func Validate(input string) []*Diagnostic {
+	var result []*Diagnostic
+	var state StyledText
+	var pending []pendingParam
+	config := newParseConfig(nil)
+	index := 0
+	for index < len(input) {
+		if next := indexSequence(input[index:]); next != 0 {
+			// Text and control characters use the pending attributes.
+			// As in Parse, an ESC that does not start a CSI or OSC
+			// sequence is text.
+			pending = pending[:0]
+			if next == -1 {
+				break
+			}
+			index += next
+			continue
+		}
+		var token Token
+		length, err := scanToken(input[index:], &token)
+		sequence := input[index : index+length]
+		if err != nil {
+			result = append(result, &Diagnostic{
+				Severity: SeverityError,
+				Offset:   index,
+				Sequence: sequence,
+				Kind:     err,
+				Message:  err.Error(),
+			})
+			index += length
+			continue
+		}
+		if token.Type != SGRToken {
+			pending = pending[:0]
+			index += length
+			continue
+		}
+		params := strings.Split(token.Params, ";")
+		for len(params) > 0 {
+			count := 1
+			if !strings.Contains(params[0], ":") {
+				switch stripLeadingZeros(params[0]) {
+				case "38", "48", "58":
+					count = strings.Count(extendedParam(params), ";") + 1
+				}
+			}
+			param := strings.Join(params[:count], ";")
+			params = params[count:]
+
+			before := state
+			if err := state.applySGR(param, &config); err != nil {
+				kind := errors.Unwrap(err)
+				message := kind.Error() + " " + param
+				if kind == ErrInvalid {
+					message = "unknown SGR parameter " + param
+				}
+				result = append(result, &Diagnostic{
+					Severity: SeverityError,
+					Offset:   index,
+					Sequence: sequence,
+					Param:    param,
+					Kind:     kind,
+					Message:  message,
+				})
+				continue
+			}
+
+			attributes := sgrAttributes(param)
+			overridden := false
+			remaining := pending[:0]
+			for _, p := range pending {
+				if p.attributes&attributes == 0 {
+					remaining = append(remaining, p)
+					continue
+				}
+				overridden = true
+				result = append(result, &Diagnostic{
+					Severity: SeverityWarning,
+					Offset:   p.offset,
+					Sequence: p.sequence,
+					Param:    p.param,
+					Message:  fmt.Sprintf("SGR %s is overridden by %s before any text", p.param, param),
+				})
+			}
+			pending = remaining
+
+			if !isResetParam(param) {
+				pending = append(pending, pendingParam{
+					offset:     index,
+					sequence:   sequence,
+					param:      param,
+					attributes: attributes,
+				})
+				continue
+			}
+			if !overridden && state == before {
+				result = append(result, &Diagnostic{
+					Severity: SeverityWarning,
+					Offset:   index,
+					Sequence: sequence,
+					Param:    param,
+					Message:  fmt.Sprintf("SGR %s resets attributes that are not set", param),
+				})
+			}
+		}
+		index += length
+	}
+	return result
+}
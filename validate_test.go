@@ -0,0 +1,157 @@
+package ansi
+
+import (
+	"strings"
+	"testing"
+
+	is "github.com/matryer/is"
+)
+
+func TestValidate(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		name  string
+		input string
+		want  []Diagnostic
+	}{
+		{"Blank", "", nil},
+		{"No formatting", "Hello World", nil},
+		{"Valid", "\033[1;31mHello\033[0m \033[38;5;208;48;2;0;0;255mWorld\033[0m", nil},
+		{"Unknown code", "Hello \033[1;99mWorld", []Diagnostic{
+			{SeverityError, 6, "\033[1;99m", "99", ErrInvalid, "unknown SGR parameter 99"},
+		}},
+		{"Out of range colours", "\033[38;5;300mA\033[48;2;0;256;0mB\033[58:5:999mC", []Diagnostic{
+			{SeverityError, 0, "\033[38;5;300m", "38;5;300", ErrInvalid256ColSequence, "invalid 256 colour sequence 38;5;300"},
+			{SeverityError, 12, "\033[48;2;0;256;0m", "48;2;0;256;0", ErrInvalidTrueColorSequence, "invalid TrueColor sequence 48;2;0;256;0"},
+			{SeverityError, 28, "\033[58:5:999m", "58:5:999", ErrInvalid256ColSequence, "invalid 256 colour sequence 58:5:999"},
+		}},
+		{"Every problem reported", "\033[99mA\033[98;1mB\033[0m", []Diagnostic{
+			{SeverityError, 0, "\033[99m", "99", ErrInvalid, "unknown SGR parameter 99"},
+			{SeverityError, 6, "\033[98;1m", "98", ErrInvalid, "unknown SGR parameter 98"},
+		}},
+		{"Unterminated", "\033[1mHello\033[31", []Diagnostic{
+			{SeverityError, 9, "\033[31", "", ErrMissingTerminator, "missing escape terminator"},
+		}},
+		{"Unterminated OSC", "\033]8;;http://a\033[1mA", []Diagnostic{
+			{SeverityError, 0, "\033]8;;http://a", "", ErrInvalid, "invalid ansi string"},
+		}},
+		{"Malformed", "\033[3\033[1mA", []Diagnostic{
+			{SeverityError, 0, "\033[3", "", ErrInvalid, "invalid ansi string"},
+		}},
+		{"Lone ESC", "abc\033", nil},
+		{"ESC not starting a sequence", "\033(Babc\033c\033\033[1mA", nil},
+		{"ESC is text", "\033[1m\033(\033[22mA", nil},
+		{"Redundant reset", "\033[0mHello", []Diagnostic{
+			{SeverityWarning, 0, "\033[0m", "0", nil, "SGR 0 resets attributes that are not set"},
+		}},
+		{"Redundant reset after reset", "\033[1mA\033[0m\033[m", []Diagnostic{
+			{SeverityWarning, 9, "\033[m", "", nil, "SGR  resets attributes that are not set"},
+		}},
+		{"Redundant attribute reset", "\033[1mA\033[22;23mB", []Diagnostic{
+			{SeverityWarning, 5, "\033[22;23m", "23", nil, "SGR 23 resets attributes that are not set"},
+		}},
+		{"Overridden in sequence", "\033[31;32mA", []Diagnostic{
+			{SeverityWarning, 0, "\033[31;32m", "31", nil, "SGR 31 is overridden by 32 before any text"},
+		}},
+		{"Overridden by next sequence", "\033[1m\033[4m\033[22mA", []Diagnostic{
+			{SeverityWarning, 0, "\033[1m", "1", nil, "SGR 1 is overridden by 22 before any text"},
+		}},
+		{"Overridden by reset", "A\033[1;38;5;208m\033[0mB", []Diagnostic{
+			{SeverityWarning, 1, "\033[1;38;5;208m", "1", nil, "SGR 1 is overridden by 0 before any text"},
+			{SeverityWarning, 1, "\033[1;38;5;208m", "38;5;208", nil, "SGR 38;5;208 is overridden by 0 before any text"},
+		}},
+		{"Used before override", "\033[31mA\033[32mB", nil},
+		{"Used by control sequence", "\033[41m\033[K\033[42mB", nil},
+		{"Reset then set", "\033[1mA\033[0;32mB", nil},
+		{"Overridden attributes", "\033[20;23;51;54;52;54;73;74;75;58;5;1;59;11;10;5;25;7;27;8;28;9;29;53;55mA", []Diagnostic{
+			{SeverityWarning, 0, "\033[20;23;51;54;52;54;73;74;75;58;5;1;59;11;10;5;25;7;27;8;28;9;29;53;55m", "20", nil, "SGR 20 is overridden by 23 before any text"},
+			{SeverityWarning, 0, "\033[20;23;51;54;52;54;73;74;75;58;5;1;59;11;10;5;25;7;27;8;28;9;29;53;55m", "51", nil, "SGR 51 is overridden by 54 before any text"},
+			{SeverityWarning, 0, "\033[20;23;51;54;52;54;73;74;75;58;5;1;59;11;10;5;25;7;27;8;28;9;29;53;55m", "52", nil, "SGR 52 is overridden by 54 before any text"},
+			{SeverityWarning, 0, "\033[20;23;51;54;52;54;73;74;75;58;5;1;59;11;10;5;25;7;27;8;28;9;29;53;55m", "73", nil, "SGR 73 is overridden by 74 before any text"},
+			{SeverityWarning, 0, "\033[20;23;51;54;52;54;73;74;75;58;5;1;59;11;10;5;25;7;27;8;28;9;29;53;55m", "74", nil, "SGR 74 is overridden by 75 before any text"},
+			{SeverityWarning, 0, "\033[20;23;51;54;52;54;73;74;75;58;5;1;59;11;10;5;25;7;27;8;28;9;29;53;55m", "58;5;1", nil, "SGR 58;5;1 is overridden by 59 before any text"},
+			{SeverityWarning, 0, "\033[20;23;51;54;52;54;73;74;75;58;5;1;59;11;10;5;25;7;27;8;28;9;29;53;55m", "11", nil, "SGR 11 is overridden by 10 before any text"},
+			{SeverityWarning, 0, "\033[20;23;51;54;52;54;73;74;75;58;5;1;59;11;10;5;25;7;27;8;28;9;29;53;55m", "5", nil, "SGR 5 is overridden by 25 before any text"},
+			{SeverityWarning, 0, "\033[20;23;51;54;52;54;73;74;75;58;5;1;59;11;10;5;25;7;27;8;28;9;29;53;55m", "7", nil, "SGR 7 is overridden by 27 before any text"},
+			{SeverityWarning, 0, "\033[20;23;51;54;52;54;73;74;75;58;5;1;59;11;10;5;25;7;27;8;28;9;29;53;55m", "8", nil, "SGR 8 is overridden by 28 before any text"},
+			{SeverityWarning, 0, "\033[20;23;51;54;52;54;73;74;75;58;5;1;59;11;10;5;25;7;27;8;28;9;29;53;55m", "9", nil, "SGR 9 is overridden by 29 before any text"},
+			{SeverityWarning, 0, "\033[20;23;51;54;52;54;73;74;75;58;5;1;59;11;10;5;25;7;27;8;28;9;29;53;55m", "53", nil, "SGR 53 is overridden by 55 before any text"},
+		}},
+		{"Overridden styles and colours", "\033[2;22;4;24;6;25;91;39;101;49;21;24;3;23mA", []Diagnostic{
+			{SeverityWarning, 0, "\033[2;22;4;24;6;25;91;39;101;49;21;24;3;23m", "2", nil, "SGR 2 is overridden by 22 before any text"},
+			{SeverityWarning, 0, "\033[2;22;4;24;6;25;91;39;101;49;21;24;3;23m", "4", nil, "SGR 4 is overridden by 24 before any text"},
+			{SeverityWarning, 0, "\033[2;22;4;24;6;25;91;39;101;49;21;24;3;23m", "6", nil, "SGR 6 is overridden by 25 before any text"},
+			{SeverityWarning, 0, "\033[2;22;4;24;6;25;91;39;101;49;21;24;3;23m", "91", nil, "SGR 91 is overridden by 39 before any text"},
+			{SeverityWarning, 0, "\033[2;22;4;24;6;25;91;39;101;49;21;24;3;23m", "101", nil, "SGR 101 is overridden by 49 before any text"},
+			{SeverityWarning, 0, "\033[2;22;4;24;6;25;91;39;101;49;21;24;3;23m", "21", nil, "SGR 21 is overridden by 24 before any text"},
+			{SeverityWarning, 0, "\033[2;22;4;24;6;25;91;39;101;49;21;24;3;23m", "3", nil, "SGR 3 is overridden by 23 before any text"},
+		}},
+		{"Redundant attribute resets", "A\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23mB", []Diagnostic{
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "22", nil, "SGR 22 resets attributes that are not set"},
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "24", nil, "SGR 24 resets attributes that are not set"},
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "25", nil, "SGR 25 resets attributes that are not set"},
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "27", nil, "SGR 27 resets attributes that are not set"},
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "28", nil, "SGR 28 resets attributes that are not set"},
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "29", nil, "SGR 29 resets attributes that are not set"},
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "39", nil, "SGR 39 resets attributes that are not set"},
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "49", nil, "SGR 49 resets attributes that are not set"},
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "54", nil, "SGR 54 resets attributes that are not set"},
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "55", nil, "SGR 55 resets attributes that are not set"},
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "59", nil, "SGR 59 resets attributes that are not set"},
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "75", nil, "SGR 75 resets attributes that are not set"},
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "10", nil, "SGR 10 resets attributes that are not set"},
+			{SeverityWarning, 1, "\033[22;24;25;27;28;29;39;49;54;55;59;75;10;23m", "23", nil, "SGR 23 resets attributes that are not set"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Validate(tt.input)
+			is2.Equal(len(got), len(tt.want))
+			for index, w := range tt.want {
+				is2.Equal(*got[index], w)
+			}
+		})
+	}
+}
+
+func TestValidateLongInput(t *testing.T) {
+	is2 := is.New(t)
+	// Each run of text is skipped in one step, so long lines
+	// without sequences take linear time
+	text := strings.Repeat("Hello World ", 1<<17)
+	got := Validate(text + "\033[0m" + text)
+	is2.Equal(len(got), 1)
+	is2.Equal(got[0].Offset, len(text))
+	is2.Equal(len(Validate(text+"\033"+text)), 0)
+}
+
+func TestSGRAttributes(t *testing.T) {
+	is2 := is.New(t)
+	tests := []struct {
+		param string
+		want  sgrAttribute
+	}{
+		{"", attrAll},
+		{"00", attrAll},
+		{"022", attrBold | attrFaint},
+		{"38;5;1", attrForeground},
+		{"48:2::1:2:3", attrBackground},
+		{"4:3", attrUnderline},
+		{"58;5;1", attrUnderlineColour},
+		{"99", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			is2.Equal(sgrAttributes(tt.param), tt.want)
+		})
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	is2 := is.New(t)
+	got := Validate("Hello \033[38;5;300mWorld")
+	is2.Equal(len(got), 1)
+	is2.Equal(got[0].String(), "6: error: invalid 256 colour sequence 38;5;300")
+	is2.Equal(SeverityWarning.String(), "warning")
+	is2.Equal(Severity(5).String(), "Severity(5)")
+}